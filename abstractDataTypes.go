@@ -1,5 +1,13 @@
 package blockingQueues
 
+import (
+	"context"
+	"math"
+)
+
+// Capacity of queues without a bound
+const Unbounded uint64 = math.MaxUint64
+
 type AbstractCollectionBase interface {
 	Size() uint64
 	Capacity() uint64
@@ -8,22 +16,55 @@ type AbstractCollectionBase interface {
 }
 
 // All Queues must implement this interface
-type Interface interface {
+type Interface[T any] interface {
 	AbstractCollectionBase
 
-	Push(item interface{}) (bool, error)
-	Pop() (interface{}, error)
+	Push(item T) (bool, error)
+	Pop() (T, error)
+
+	Get() (T, error)
+	Put(item T) (bool, error)
+	Offer(item T) bool
+
+	GetContext(ctx context.Context) (T, error)
+	PutContext(ctx context.Context, item T) (bool, error)
 
-	Get() (interface{}, error)
-	Put(item interface{}) (bool, error)
-	Offer(item interface{}) bool
+	Peek() T
+}
+
+// Positional storage backing a BlockingQueue. Set is called with the tail
+// position and Get and Remove with the head position, so stores keeping
+// their own order (LinkedListStore, HeapStore) may ignore pos.
+// Items copies count items starting at the head position, in the order
+// they would be removed. RemoveIf removes the items matching pred, keeping
+// the others packed from the head position, and returns how many it removed.
+// Resize changes the store size to hold at least count items, moving
+// them to start at position 0
+type QueueStore[T any] interface {
+	Set(value T, pos uint64)
+	Remove(pos uint64) T
+	Get(pos uint64) T
+	Items(pos uint64, count uint64) []T
+	RemoveIf(pos uint64, count uint64, pred func(T) bool) uint64
+	Resize(pos uint64, count uint64, size uint64) error
+	Size() uint64
+}
 
-	Peek() interface{}
+// Converts items to and from bytes for the stores writing them to disk.
+// Encode may be called by several producers at once
+type Codec[T any] interface {
+	Encode(item T) ([]byte, error)
+	Decode(data []byte) (T, error)
 }
 
-type QueueStore interface {
-	Set(value interface{}, pos uint64)
-	Remove(pos uint64) interface{}
-	Get(pos uint64) interface{}
+// Storage backing a BlockingDeque, with insertion and removal at both ends
+type DequeStore[T any] interface {
+	PushFront(value T)
+	PushBack(value T)
+	PopFront() T
+	PopBack() T
+	Front() T
+	Back() T
+	Items() []T
 	Size() uint64
 }
@@ -0,0 +1,78 @@
+package blockingQueues
+
+// ArrayDequeStore is a ring over an ArrayStore that grows at both ends
+type ArrayDequeStore[T any] struct {
+	ring *ArrayStore[T]
+
+	// ring index of the first item
+	head uint64
+
+	// The number of items in the ring
+	count uint64
+}
+
+func NewArrayDequeStore[T any](size uint64) *ArrayDequeStore[T] {
+	return &ArrayDequeStore[T]{
+		ring: NewArrayStore[T](size),
+	}
+}
+
+// Returns the ring index that is offset items after head
+func (s *ArrayDequeStore[T]) index(offset uint64) uint64 {
+	return (s.head + offset) % s.ring.Size()
+}
+
+func (s *ArrayDequeStore[T]) PushFront(value T) {
+	s.head = s.index(s.ring.Size() - 1)
+	s.ring.Set(value, s.head)
+	s.count += 1
+}
+
+func (s *ArrayDequeStore[T]) PushBack(value T) {
+	s.ring.Set(value, s.index(s.count))
+	s.count += 1
+}
+
+func (s *ArrayDequeStore[T]) PopFront() T {
+	var item = s.ring.Remove(s.head)
+	s.head = s.index(1)
+	s.count -= 1
+	return item
+}
+
+func (s *ArrayDequeStore[T]) PopBack() T {
+	s.count -= 1
+	return s.ring.Remove(s.index(s.count))
+}
+
+func (s *ArrayDequeStore[T]) Front() T {
+	return s.ring.Get(s.head)
+}
+
+func (s *ArrayDequeStore[T]) Back() T {
+	return s.ring.Get(s.index(s.count - 1))
+}
+
+func (s *ArrayDequeStore[T]) Items() []T {
+	return s.ring.Items(s.head, s.count)
+}
+
+func (s ArrayDequeStore[T]) Size() uint64 {
+	return s.ring.Size()
+}
+
+// Creates an BlockingDeque backed by an Array with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewArrayBlockingDeque(capacity uint64) (*BlockingDeque[interface{}], error) {
+	return NewArrayBlockingDequeOf[interface{}](capacity)
+}
+
+// Creates an BlockingDeque of T backed by an Array with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewArrayBlockingDequeOf[T any](capacity uint64) (*BlockingDeque[T], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
+
+	return newBlockingDeque[T](NewArrayDequeStore[T](capacity)), nil
+}
@@ -1,49 +1,112 @@
 package blockingQueues
 
-import "sync"
-
-type ArrayStore struct {
-	store []interface{}
+type ArrayStore[T any] struct {
+	store []T
 }
 
-func NewArrayStore(size uint64) *ArrayStore {
-	return &ArrayStore{
-		store: make([]interface{}, size),
+func NewArrayStore[T any](size uint64) *ArrayStore[T] {
+	return &ArrayStore[T]{
+		store: make([]T, size),
 	}
 }
 
-func (s *ArrayStore) Set(value interface{}, pos uint64) {
+func (s *ArrayStore[T]) Set(value T, pos uint64) {
 	s.store[pos] = value
 }
 
-func (s *ArrayStore) Get(pos uint64) interface{} {
+func (s *ArrayStore[T]) Get(pos uint64) T {
 	return s.store[pos]
 }
 
-func (s *ArrayStore) Remove(pos uint64) interface{} {
+func (s *ArrayStore[T]) Remove(pos uint64) T {
 	var item = s.store[pos]
-	s.store[pos] = nil
+	var zero T
+	s.store[pos] = zero
 	return item
 }
 
-func (s ArrayStore) Size() uint64 {
+func (s *ArrayStore[T]) Items(pos uint64, count uint64) []T {
+	var res = make([]T, count)
+	var size = uint64(len(s.store))
+	for i := range res {
+		res[i] = s.store[(pos+uint64(i))%size]
+	}
+
+	return res
+}
+
+// Compacts the kept items towards the head, preserving their order
+func (s *ArrayStore[T]) RemoveIf(pos uint64, count uint64, pred func(T) bool) uint64 {
+	var size = uint64(len(s.store))
+	var kept = uint64(0)
+	var zero T
+
+	for i := uint64(0); i < count; i += 1 {
+		var item = s.store[(pos+i)%size]
+		if !pred(item) {
+			s.store[(pos+kept)%size] = item
+			kept += 1
+		}
+	}
+
+	for i := kept; i < count; i += 1 {
+		s.store[(pos+i)%size] = zero
+	}
+
+	return count - kept
+}
+
+// Copies the items into a new array of the given size.
+// Returns an error if the size is Unbounded
+func (s *ArrayStore[T]) Resize(pos uint64, count uint64, size uint64) error {
+	if size == Unbounded {
+		return ErrorCapacity
+	}
+
+	var store = make([]T, size)
+	var prev = uint64(len(s.store))
+	for i := uint64(0); i < count; i += 1 {
+		store[i] = s.store[(pos+i)%prev]
+	}
+	s.store = store
+
+	return nil
+}
+
+func (s ArrayStore[T]) Size() uint64 {
 	return uint64(len(s.store))
 }
 
 // Creates an BlockingQueue backed by an Array with the given (fixed) capacity
 // returns an error if the capacity is less than 1
-func NewArrayBlockingQueue(capacity uint64) (*BlockingQueue, error) {
+func NewArrayBlockingQueue(capacity uint64) (*BlockingQueue[interface{}], error) {
+	return NewArrayBlockingQueueOf[interface{}](capacity)
+}
+
+// Creates an BlockingQueue of T backed by an Array with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewArrayBlockingQueueOf[T any](capacity uint64) (*BlockingQueue[T], error) {
 	if capacity < 1 {
 		return nil, ErrorCapacity
 	}
 
-	lock := new(sync.Mutex)
+	return newBlockingQueue[T](NewArrayStore[T](capacity)), nil
+}
+
+// Creates an BlockingQueue backed by an Array with the given (fixed) capacity
+// where blocked producers and consumers are served in arrival order.
+// returns an error if the capacity is less than 1
+func NewArrayBlockingQueueFair(capacity uint64) (*BlockingQueue[interface{}], error) {
+	return NewArrayBlockingQueueFairOf[interface{}](capacity)
+}
+
+// Creates an BlockingQueue of T backed by an Array with the given (fixed) capacity
+// where blocked producers and consumers are served in arrival order.
+// returns an error if the capacity is less than 1
+func NewArrayBlockingQueueFairOf[T any](capacity uint64) (*BlockingQueue[T], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
 
-	return &BlockingQueue{
-		lock:     lock,
-		notEmpty: sync.NewCond(lock),
-		notFull:  sync.NewCond(lock),
-		count:    uint64(0),
-		store:    NewArrayStore(capacity),
-	}, nil
+	return newFairBlockingQueue[T](NewArrayStore[T](capacity)), nil
 }
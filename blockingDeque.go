@@ -0,0 +1,328 @@
+package blockingQueues
+
+import (
+	"context"
+	"sync"
+)
+
+/**
+ * BlockingDeque is A multi-producer, multi-consumer double ended queue
+ * of T items. Used as a queue it puts at the tail and takes from the head
+ */
+
+type BlockingDeque[T any] struct {
+	// The number of items in the Deque
+	count uint64
+
+	// Main lock guarding all access
+	lock *sync.Mutex
+
+	// Condition for waiting reads
+	notEmpty *sync.Cond
+
+	// Condition for waiting writes
+	notFull *sync.Cond
+
+	// The underling store
+	store DequeStore[T]
+}
+
+// Creates a BlockingDeque on top of the given store
+func newBlockingDeque[T any](store DequeStore[T]) *BlockingDeque[T] {
+	lock := new(sync.Mutex)
+
+	return &BlockingDeque[T]{
+		lock:     lock,
+		notEmpty: sync.NewCond(lock),
+		notFull:  sync.NewCond(lock),
+		count:    uint64(0),
+		store:    store,
+	}
+}
+
+var _ Interface[interface{}] = (*BlockingDeque[interface{}])(nil)
+
+// Size returns this current elements size, is concurrent safe
+func (q *BlockingDeque[T]) Size() uint64 {
+	q.lock.Lock()
+	res := q.count
+	q.lock.Unlock()
+
+	return res
+}
+
+// Capacity returns this current elements remaining capacity, is concurrent safe
+func (q *BlockingDeque[T]) Capacity() uint64 {
+	q.lock.Lock()
+	res := uint64(q.store.Size() - q.count)
+	q.lock.Unlock()
+
+	return res
+}
+
+func (q *BlockingDeque[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Clears all the deques elements, signals waiters for deque is empty
+func (q *BlockingDeque[T]) Clear() {
+	q.lock.Lock()
+
+	for ; q.count > 0; q.count -= 1 {
+		q.store.PopFront()
+	}
+	q.notFull.Broadcast()
+	q.lock.Unlock()
+}
+
+// Inserts element at the head or the tail if there is room, and signals.
+// Call only when holding lock.
+func (q *BlockingDeque[T]) tryPush(item T, first bool) (bool, error) {
+	if q.count == q.store.Size() {
+		return false, ErrorFull
+	}
+
+	if first {
+		q.store.PushFront(item)
+	} else {
+		q.store.PushBack(item)
+	}
+	q.count += 1
+	q.notEmpty.Signal()
+
+	return true, nil
+}
+
+// Removes element from the head or the tail if there is one, and signals.
+// Call only when holding lock.
+func (q *BlockingDeque[T]) tryPop(first bool) (res T, err error) {
+	if q.count == 0 {
+		return res, ErrorEmpty
+	}
+
+	if first {
+		res = q.store.PopFront()
+	} else {
+		res = q.store.PopBack()
+	}
+	q.count -= 1
+	q.notFull.Signal()
+
+	return res, nil
+}
+
+func (q *BlockingDeque[T]) push(item T, first bool) (bool, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.lock.Lock()
+	var res, err = q.tryPush(item, first)
+	q.lock.Unlock()
+
+	return res, err
+}
+
+func (q *BlockingDeque[T]) pop(first bool) (T, error) {
+	q.lock.Lock()
+	var res, err = q.tryPop(first)
+	q.lock.Unlock()
+
+	return res, err
+}
+
+func (q *BlockingDeque[T]) peek(first bool) T {
+	q.lock.Lock()
+
+	var res T
+
+	if q.count > 0 && first {
+		res = q.store.Front()
+	} else if q.count > 0 {
+		res = q.store.Back()
+	}
+	q.lock.Unlock()
+
+	return res
+}
+
+func (q *BlockingDeque[T]) get(ctx context.Context, first bool) (T, error) {
+	q.lock.Lock()
+
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, func() {
+		q.lock.Lock()
+		q.notEmpty.Broadcast()
+		q.lock.Unlock()
+	})
+
+	for q.count == 0 {
+		if err := ctx.Err(); err != nil {
+			q.lock.Unlock()
+			stop()
+
+			var zero T
+			return zero, err
+		}
+		// We wait here until the deque has an item
+		q.notEmpty.Wait()
+	}
+
+	// Critical section after wait released and predicate is false
+	var item, err = q.tryPop(first)
+	q.lock.Unlock()
+	stop()
+
+	return item, err
+}
+
+func (q *BlockingDeque[T]) put(ctx context.Context, item T, first bool) (bool, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.lock.Lock()
+
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, func() {
+		q.lock.Lock()
+		q.notFull.Broadcast()
+		q.lock.Unlock()
+	})
+
+	for q.count == q.store.Size() {
+		if err := ctx.Err(); err != nil {
+			q.lock.Unlock()
+			stop()
+
+			return false, err
+		}
+		// We wait here until the deque has an empty slot
+		q.notFull.Wait()
+	}
+
+	// Critical section after wait released and predicate is false
+	var res, err = q.tryPush(item, first)
+	q.lock.Unlock()
+	stop()
+
+	return res, err
+}
+
+// Pushes the specified element at the head of the deque.
+// Does not block the current goroutine
+func (q *BlockingDeque[T]) PushFirst(item T) (bool, error) {
+	return q.push(item, true)
+}
+
+// Pushes the specified element at the tail of the deque.
+// Does not block the current goroutine
+func (q *BlockingDeque[T]) PushLast(item T) (bool, error) {
+	return q.push(item, false)
+}
+
+// Inserts the specified element at the head of the deque if there is room,
+// returning true upon success and false if the deque is full.
+// Does not block the current goroutine
+func (q *BlockingDeque[T]) OfferFirst(item T) bool {
+	res, _ := q.push(item, true)
+	return res
+}
+
+// Inserts the specified element at the tail of the deque if there is room,
+// returning true upon success and false if the deque is full.
+// Does not block the current goroutine
+func (q *BlockingDeque[T]) OfferLast(item T) bool {
+	res, _ := q.push(item, false)
+	return res
+}
+
+// Pops an element from the head of the deque.
+// Does not block the current goroutine
+func (q *BlockingDeque[T]) PopFirst() (T, error) {
+	return q.pop(true)
+}
+
+// Pops an element from the tail of the deque.
+// Does not block the current goroutine
+func (q *BlockingDeque[T]) PopLast() (T, error) {
+	return q.pop(false)
+}
+
+// Just attempts to return the head element of the deque
+func (q *BlockingDeque[T]) PeekFirst() T {
+	return q.peek(true)
+}
+
+// Just attempts to return the tail element of the deque
+func (q *BlockingDeque[T]) PeekLast() T {
+	return q.peek(false)
+}
+
+// Puts an element to the head of the deque.
+// It blocks the current goroutine if the deque is Full until notified
+func (q *BlockingDeque[T]) PutFirst(item T) (bool, error) {
+	return q.put(context.Background(), item, true)
+}
+
+// Puts an element to the tail of the deque.
+// It blocks the current goroutine if the deque is Full until notified
+func (q *BlockingDeque[T]) PutLast(item T) (bool, error) {
+	return q.put(context.Background(), item, false)
+}
+
+// Takes an element from the head of the deque.
+// It blocks the current goroutine if the deque is Empty until notified
+func (q *BlockingDeque[T]) GetFirst() (T, error) {
+	return q.get(context.Background(), true)
+}
+
+// Takes an element from the tail of the deque.
+// It blocks the current goroutine if the deque is Empty until notified
+func (q *BlockingDeque[T]) GetLast() (T, error) {
+	return q.get(context.Background(), false)
+}
+
+// Same as PushLast
+func (q *BlockingDeque[T]) Push(item T) (bool, error) {
+	return q.PushLast(item)
+}
+
+// Same as PopFirst
+func (q *BlockingDeque[T]) Pop() (T, error) {
+	return q.PopFirst()
+}
+
+// Same as OfferLast
+func (q *BlockingDeque[T]) Offer(item T) bool {
+	return q.OfferLast(item)
+}
+
+// Same as PeekFirst
+func (q *BlockingDeque[T]) Peek() T {
+	return q.PeekFirst()
+}
+
+// Same as PutLast
+func (q *BlockingDeque[T]) Put(item T) (bool, error) {
+	return q.PutLast(item)
+}
+
+// Same as GetFirst
+func (q *BlockingDeque[T]) Get() (T, error) {
+	return q.GetFirst()
+}
+
+// Takes an element from the head of the deque.
+// It blocks the current goroutine if the deque is Empty until notified
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *BlockingDeque[T]) GetContext(ctx context.Context) (T, error) {
+	return q.get(ctx, true)
+}
+
+// Puts an element to the tail of the deque.
+// It blocks the current goroutine if the deque is Full until notified
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *BlockingDeque[T]) PutContext(ctx context.Context, item T) (bool, error) {
+	return q.put(ctx, item, false)
+}
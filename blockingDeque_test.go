@@ -0,0 +1,167 @@
+package blockingQueues
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type BlockingDequeSuite struct {
+	newDeque func(capacity uint64) (*BlockingDeque[interface{}], error)
+	deque    *BlockingDeque[interface{}]
+}
+
+var _ = Suite(&BlockingDequeSuite{newDeque: NewArrayBlockingDeque})
+var _ = Suite(&BlockingDequeSuite{newDeque: NewLinkedBlockingDeque})
+
+func (s *BlockingDequeSuite) SetUpTest(c *C) {
+	s.deque, _ = s.newDeque(4)
+}
+
+func (s *BlockingDequeSuite) TestInvalidCapacity(c *C) {
+	_, err := s.newDeque(0)
+	c.Assert(err, ErrorMatches, "ERROR_CAPACITY: attempt to Create Queue with invalid Capacity")
+}
+
+func (s *BlockingDequeSuite) TestBothEnds(c *C) {
+	s.deque.PushLast(2)
+	s.deque.PushFirst(1)
+	s.deque.OfferLast(3)
+	s.deque.OfferFirst(0)
+
+	c.Assert(s.deque.Size(), Equals, uint64(4))
+	c.Assert(s.deque.PeekFirst(), Equals, 0)
+	c.Assert(s.deque.PeekLast(), Equals, 3)
+	c.Assert(s.deque.OfferFirst(-1), Equals, false)
+
+	res, err := s.deque.PushLast(4)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorFull)
+
+	item, _ := s.deque.PopLast()
+	c.Assert(item, Equals, 3)
+	item, _ = s.deque.PopFirst()
+	c.Assert(item, Equals, 0)
+	item, _ = s.deque.PopLast()
+	c.Assert(item, Equals, 2)
+	item, _ = s.deque.PopFirst()
+	c.Assert(item, Equals, 1)
+
+	_, err = s.deque.PopLast()
+	c.Assert(err, Equals, ErrorEmpty)
+	c.Assert(s.deque.PeekFirst(), IsNil)
+	c.Assert(s.deque.PeekLast(), IsNil)
+}
+
+func (s *BlockingDequeSuite) TestWrapAround(c *C) {
+	s.deque.PushLast(0)
+	s.deque.PushLast(1)
+
+	// Slide the window forwards around the ring
+	for i := 2; i < 12; i += 1 {
+		s.deque.PushLast(i)
+		item, _ := s.deque.PopFirst()
+		c.Assert(item, Equals, i-2)
+		c.Assert(s.deque.PeekLast(), Equals, i)
+	}
+
+	// Slide the window backwards around the ring
+	for i := 9; i >= 0; i -= 1 {
+		s.deque.PushFirst(i)
+		item, _ := s.deque.PopLast()
+		c.Assert(item, Equals, i+2)
+		c.Assert(s.deque.PeekFirst(), Equals, i)
+	}
+
+	item, _ := s.deque.Pop()
+	c.Assert(item, Equals, 0)
+	item, _ = s.deque.Pop()
+	c.Assert(item, Equals, 1)
+}
+
+func (s *BlockingDequeSuite) TestAsStack(c *C) {
+	for i := 0; i < 4; i += 1 {
+		s.deque.PutFirst(i)
+	}
+
+	for i := 3; i >= 0; i -= 1 {
+		item, err := s.deque.GetFirst()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, i)
+	}
+}
+
+func (s *BlockingDequeSuite) TestPutFirstBlocks(c *C) {
+	for i := 0; i < 4; i += 1 {
+		s.deque.Push(i)
+	}
+
+	done := make(chan bool)
+	go func() {
+		s.deque.PutFirst(-1)
+		done <- true
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-done:
+		c.Error("PutFirst did not block")
+	default:
+	}
+
+	item, _ := s.deque.GetLast()
+	c.Assert(item, Equals, 3)
+	<-done
+	c.Assert(s.deque.PeekFirst(), Equals, -1)
+}
+
+func (s *BlockingDequeSuite) TestGetLastBlocks(c *C) {
+	done := make(chan interface{})
+	go func() {
+		item, _ := s.deque.GetLast()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.deque.PutLast(1)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *BlockingDequeSuite) TestContext(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := s.deque.GetContext(ctx)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	for i := 0; i < 4; i += 1 {
+		s.deque.Put(i)
+	}
+
+	res, err := s.deque.PutContext(ctx, 4)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *BlockingDequeSuite) TestClear(c *C) {
+	for i := 0; i < 4; i += 1 {
+		s.deque.PushFirst(i)
+	}
+
+	s.deque.Clear()
+	c.Assert(s.deque.IsEmpty(), Equals, true)
+	c.Assert(s.deque.Capacity(), Equals, uint64(4))
+
+	s.deque.PushLast(1)
+	c.Assert(s.deque.PeekFirst(), Equals, 1)
+}
+
+func (s *BlockingDequeSuite) BenchmarkPut1to1(c *C) {
+	q, _ := s.newDeque(1024)
+	benchmarkPut(c, 1, 1, q)
+}
+
+func (s *BlockingDequeSuite) BenchmarkPut4to4(c *C) {
+	q, _ := s.newDeque(1024)
+	benchmarkPut(c, 4, 4, q)
+}
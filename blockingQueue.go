@@ -1,15 +1,21 @@
 package blockingQueues
 
 import (
+	"container/list"
+	"context"
+	"io"
 	"math"
+	"reflect"
 	"sync"
+	"time"
+	"unsafe"
 )
 
 /**
- * BlockingQueue is A multi-producer, multi-consumer queue
+ * BlockingQueue is A multi-producer, multi-consumer queue of T items
  */
 
-type BlockingQueue struct {
+type BlockingQueue[T any] struct {
 	// The number of items in the Queue
 	count uint64
 
@@ -28,12 +34,81 @@ type BlockingQueue struct {
 	// store index for next read or remove
 	readIndex uint64
 
+	// Changes whenever elements are removed, so DrainToQueue can tell
+	// whether the head it offered is still there
+	taken uint64
+
 	// The underling store
-	store QueueStore
+	store QueueStore[T]
+
+	// Maximum number of elements, may be lower than the store size
+	capacity uint64
+
+	// Whether the queue has been closed
+	closed bool
+
+	// Closed when the queue gets closed
+	done chan struct{}
+
+	// Whether blocked producers and consumers are served in arrival order
+	fair bool
+
+	// Producers blocked in a fair queue, in arrival order
+	putWaiters *list.List
+
+	// Consumers blocked in a fair queue, in arrival order
+	getWaiters *list.List
+
+	// Channel feeding the queue, created by In
+	in chan T
+
+	// Channel fed by the queue, created by Out
+	out chan T
+
+	// Receives the queue events, if any
+	metrics Metrics
+}
+
+// Creates a BlockingQueue on top of the given store
+func newBlockingQueue[T any](store QueueStore[T]) *BlockingQueue[T] {
+	lock := new(sync.Mutex)
+
+	return &BlockingQueue[T]{
+		lock:     lock,
+		notEmpty: sync.NewCond(lock),
+		notFull:  sync.NewCond(lock),
+		count:    uint64(0),
+		store:    store,
+		capacity: store.Size(),
+		done:     make(chan struct{}),
+	}
+}
+
+// Creates a BlockingQueue on top of the given store serving
+// blocked producers and consumers in arrival order
+func newFairBlockingQueue[T any](store QueueStore[T]) *BlockingQueue[T] {
+	q := newBlockingQueue[T](store)
+	q.fair = true
+	q.putWaiters = list.New()
+	q.getWaiters = list.New()
+
+	return q
+}
+
+var _ Interface[interface{}] = (*BlockingQueue[interface{}])(nil)
+
+// Arranges to call f once ctx is done, like context.AfterFunc,
+// but without any cost for contexts that are never done
+func afterFunc(ctx context.Context, f func()) (stop func() bool) {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+
+	return context.AfterFunc(ctx, f)
 }
 
 // Returns the next increment of idx. Circulates the index
-func (q *BlockingQueue) inc(idx uint64) uint64 {
+func (q *BlockingQueue[T]) inc(idx uint64) uint64 {
 	if idx >= math.MaxUint64 {
 		panic("Overflow")
 	}
@@ -46,7 +121,7 @@ func (q *BlockingQueue) inc(idx uint64) uint64 {
 }
 
 // Size returns this current elements size, is concurrent safe
-func (q *BlockingQueue) Size() uint64 {
+func (q *BlockingQueue[T]) Size() uint64 {
 	q.lock.Lock()
 	res := q.count
 	q.lock.Unlock()
@@ -54,66 +129,230 @@ func (q *BlockingQueue) Size() uint64 {
 	return res
 }
 
-// Capacity returns this current elements remaining capacity, is concurrent safe
-func (q *BlockingQueue) Capacity() uint64 {
+// Capacity returns this current elements remaining capacity, is concurrent safe.
+// Returns Unbounded if the queue has no bound
+func (q *BlockingQueue[T]) Capacity() uint64 {
 	q.lock.Lock()
-	res := uint64(q.store.Size() - q.count)
+	res := q.remaining()
+	if q.capacity == Unbounded {
+		res = Unbounded
+	}
 	q.lock.Unlock()
 
 	return res
 }
 
+// Returns how many elements can be added before the queue is full.
+// Call only when holding lock.
+func (q *BlockingQueue[T]) remaining() uint64 {
+	if q.count >= q.capacity {
+		return 0
+	}
+
+	return q.capacity - q.count
+}
+
+// Changes the maximum number of elements of the queue, waking producers
+// blocked on the previous bound. Elements beyond a lowered bound are kept
+// and new elements are refused until the queue drains below it.
+// returns an error if the capacity is less than 1
+func (q *BlockingQueue[T]) SetCapacity(capacity uint64) error {
+	if capacity < 1 {
+		return ErrorCapacity
+	}
+
+	q.lock.Lock()
+
+	// Never make the store smaller than its content
+	var size = max(capacity, q.count)
+	if err := q.store.Resize(q.readIndex, q.count, size); err != nil {
+		q.lock.Unlock()
+		return err
+	}
+
+	// The store has been re-linearised from its head
+	q.readIndex = 0
+	q.writeIndex = q.advance(0, q.count)
+
+	var before = q.remaining()
+	q.capacity = capacity
+	if after := q.remaining(); after > before {
+		q.signalNotFull(int(min(after-before, math.MaxInt32)))
+	}
+	q.lock.Unlock()
+
+	return nil
+}
+
+// Stores refusing some items before they are added
+type itemChecker[T any] interface {
+	// Returns the data to store for item, or an error if item cannot be stored
+	checkItem(item T) ([]byte, error)
+}
+
+// Stores taking the data their checkItem returned instead of the item alone
+type encodedSetter[T any] interface {
+	setEncoded(value T, data []byte, pos uint64)
+}
+
+// Panics on a Null item, returns the error of the store if it refuses item
+// and otherwise the data to store for it.
+// Call before taking the lock
+func (q *BlockingQueue[T]) check(item T) ([]byte, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	if store, ok := q.store.(itemChecker[T]); ok {
+		return store.checkItem(item)
+	}
+
+	return nil, nil
+}
+
+// Checks items in order, returning the data to store for each of the
+// accepted ones and the error of the store for the first refused one.
+// Call before taking the lock
+func (q *BlockingQueue[T]) checkAll(items []T) ([][]byte, error) {
+	var data = make([][]byte, 0, len(items))
+	for _, item := range items {
+		encoded, err := q.check(item)
+		if err != nil {
+			return data, err
+		}
+		data = append(data, encoded)
+	}
+
+	return data, nil
+}
+
 // Push element at current write position, advances, and signals.
 // Call only when holding lock.
-func (q *BlockingQueue) push(item interface{}) {
-	q.store.Set(item, q.writeIndex)
+func (q *BlockingQueue[T]) push(item T, data []byte) {
+	q.enqueue(item, data)
+	q.signalNotEmpty(1)
+}
+
+// Push element at current write position and advances without signaling.
+// Call only when holding lock.
+func (q *BlockingQueue[T]) enqueue(item T, data []byte) {
+	if store, ok := q.store.(encodedSetter[T]); ok {
+		store.setEncoded(item, data, q.writeIndex)
+	} else {
+		q.store.Set(item, q.writeIndex)
+	}
 	q.writeIndex = q.inc(q.writeIndex)
 	q.count += 1
-	q.notEmpty.Signal()
+
+	if q.metrics != nil {
+		q.metrics.OnEnqueue(q.count)
+	}
+}
+
+// Signals waiting readers that n items were enqueued.
+// Call only when holding lock.
+func (q *BlockingQueue[T]) signalNotEmpty(n int) {
+	if q.fair {
+		q.handOff()
+	} else if n == 1 {
+		q.notEmpty.Signal()
+	} else if n > 1 {
+		q.notEmpty.Broadcast()
+	}
+}
+
+// Signals waiting writers that n slots were freed.
+// Call only when holding lock.
+func (q *BlockingQueue[T]) signalNotFull(n int) {
+	if q.fair {
+		q.handOff()
+	} else if n == 1 {
+		q.notFull.Signal()
+	} else if n > 1 {
+		q.notFull.Broadcast()
+	}
 }
 
 // Pops element at current read position, advances, and signals.
 // Call only when holding lock.
-func (q *BlockingQueue) pop() (item interface{}) {
+func (q *BlockingQueue[T]) pop() (item T) {
+	item = q.dequeue()
+	q.signalNotFull(1)
+
+	return
+}
+
+// Pops element at current read position and advances without signaling.
+// Call only when holding lock.
+func (q *BlockingQueue[T]) dequeue() (item T) {
 	item = q.store.Remove(q.readIndex)
 	q.readIndex = q.inc(q.readIndex)
 	q.count -= 1
-	q.notFull.Signal()
+	q.taken += 1
+
+	if q.metrics != nil {
+		q.metrics.OnDequeue(q.count)
+	}
+	q.closeStore()
 
 	return
 }
 
+// Closes the store if it is an io.Closer once the queue is closed and
+// drained, so the elements taken after Close are still recorded.
+// Call only when holding lock.
+func (q *BlockingQueue[T]) closeStore() {
+	if !q.closed || q.count > 0 {
+		return
+	}
+
+	if closer, ok := q.store.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
 // Pushes the specified element at the tail of the queue.
+// Returns the error of the store if it refuses the item.
 // Does not block the current goroutine
-func (q *BlockingQueue) Push(item interface{}) (bool, error) {
-	if q.Offer(item) {
-		return true, nil
-	} else {
-		return false, ErrorFull
+func (q *BlockingQueue[T]) Push(item T) (bool, error) {
+	data, err := q.check(item)
+	if err != nil {
+		return false, err
 	}
+
+	q.lock.Lock()
+	res, err := q.tryPush(item, data)
+	q.lock.Unlock()
+
+	return res, err
 }
 
 // Inserts the specified element at the tail of this queue if it is possible to
 // do so immediately without exceeding the queue's capacity,
-// returning true upon success and false if this queue is full.
+// returning true upon success and false if this queue is full
+// or the store refuses the item.
 // Does not block the current goroutine
-func (q *BlockingQueue) Offer(item interface{}) (res bool) {
-	if item == nil {
-		panic("Null item")
+func (q *BlockingQueue[T]) Offer(item T) (res bool) {
+	data, err := q.check(item)
+	if err != nil {
+		return false
 	}
 
 	q.lock.Lock()
-	res, _ = q.tryPush(item)
+	res, _ = q.tryPush(item, data)
 	q.lock.Unlock()
 
 	return
 }
 
-func (q *BlockingQueue) tryPush(item interface{}) (res bool, err error) {
-	if q.count == q.store.Size() {
+func (q *BlockingQueue[T]) tryPush(item T, data []byte) (res bool, err error) {
+	if q.closed {
+		res, err = false, ErrorClosed
+	} else if q.remaining() == 0 {
 		res, err = false, ErrorFull
+		q.reject(err)
 	} else {
-		q.push(item)
+		q.push(item, data)
 		res, err = true, nil
 	}
 	return
@@ -121,7 +360,7 @@ func (q *BlockingQueue) tryPush(item interface{}) (res bool, err error) {
 
 // Pops an element from the head of the queue.
 // Does not block the current goroutine
-func (q *BlockingQueue) Pop() (res interface{}, err error) {
+func (q *BlockingQueue[T]) Pop() (res T, err error) {
 	q.lock.Lock()
 	res, err = q.tryPop()
 	q.lock.Unlock()
@@ -129,10 +368,14 @@ func (q *BlockingQueue) Pop() (res interface{}, err error) {
 	return res, err
 }
 
-func (q *BlockingQueue) tryPop() (res interface{}, err error) {
-	if q.count == 0 {
+func (q *BlockingQueue[T]) tryPop() (res T, err error) {
+	if q.count == 0 && q.closed {
+		// Case drained after close
+		err = ErrorClosed
+	} else if q.count == 0 {
 		// Case empty
-		res, err = nil, ErrorEmpty
+		err = ErrorEmpty
+		q.reject(err)
 	} else {
 		var item = q.pop()
 		res, err = item, nil
@@ -142,15 +385,12 @@ func (q *BlockingQueue) tryPop() (res interface{}, err error) {
 }
 
 // Just attempts to return the tail element of the queue
-func (q BlockingQueue) Peek() interface{} {
+func (q BlockingQueue[T]) Peek() T {
 	q.lock.Lock()
 
-	var res interface{}
+	var res T
 
-	if q.count == 0 {
-		// Case empty
-		res = nil
-	} else {
+	if q.count > 0 {
 		var item = q.store.Get(q.readIndex)
 		res = item
 	}
@@ -159,61 +399,525 @@ func (q BlockingQueue) Peek() interface{} {
 	return res
 }
 
-func (q BlockingQueue) IsEmpty() bool {
+func (q BlockingQueue[T]) IsEmpty() bool {
 	return q.Size() == 0
 }
 
 // Clears all the queues elements, cleans up, signals waiters for queue is empty
-func (q *BlockingQueue) Clear() {
+func (q *BlockingQueue[T]) Clear() {
 	q.lock.Lock()
 
 	// Start from head up to the tail
 	next := q.readIndex
+	cleared := q.count
 
 	for i := uint64(0); i < q.count; i += 1 {
 		q.store.Remove(next)
 		next = q.inc(next)
 	}
+	// The head catches up with the tail, so stores tracking
+	// the positions themselves stay in sync
 	q.count = uint64(0)
-	q.readIndex = uint64(0)
-	q.writeIndex = uint64(0)
-	q.notFull.Broadcast()
+	q.readIndex = next
+	q.taken += cleared
+	q.signalNotFull(int(cleared))
+	q.closeStore()
+	q.lock.Unlock()
+}
+
+// Returns the index n positions after idx. Circulates the index
+func (q *BlockingQueue[T]) advance(idx uint64, n uint64) uint64 {
+	var size = q.store.Size()
+	if n >= size-idx {
+		return n - (size - idx)
+	}
+
+	return idx + n
+}
+
+// Panics if item is not comparable. Elements of other types are never
+// equal to item, so comparing them to it cannot panic
+func checkComparable(item any) {
+	if t := reflect.TypeOf(item); t != nil && !t.Comparable() {
+		panic("Uncomparable item")
+	}
+}
+
+// Reports whether the queue holds an element equal to item.
+// Panics if item is not comparable
+func (q *BlockingQueue[T]) Contains(item T) bool {
+	checkComparable(item)
+
+	q.lock.Lock()
+	var items = q.store.Items(q.readIndex, q.count)
+	q.lock.Unlock()
+
+	for _, other := range items {
+		if any(other) == any(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Removes the first element equal to item, returning true if one was found.
+// Panics if item is not comparable
+func (q *BlockingQueue[T]) Remove(item T) bool {
+	checkComparable(item)
+
+	var found = false
+
+	q.RemoveIf(func(other T) bool {
+		if !found && any(other) == any(item) {
+			found = true
+			return true
+		}
+		return false
+	})
+
+	return found
+}
+
+// Removes all the elements matching pred, returning how many were removed,
+// and signals waiters for the freed capacity.
+// pred is called while holding the lock so it must not use the queue
+func (q *BlockingQueue[T]) RemoveIf(pred func(T) bool) int {
+	q.lock.Lock()
+
+	removed := q.store.RemoveIf(q.readIndex, q.count, pred)
+	q.count -= removed
+	q.taken += removed
+	q.writeIndex = q.advance(q.readIndex, q.count)
+	q.signalNotFull(int(removed))
+	q.closeStore()
+	q.lock.Unlock()
+
+	return int(removed)
+}
+
+// Takes an element from the head of the queue.
+// It blocks the current goroutine if the queue is Empty until notified
+func (q *BlockingQueue[T]) Get() (T, error) {
+	if q.fair {
+		return q.fairGet(context.Background())
+	}
+
+	q.lock.Lock()
+
+	var start time.Time
+	for q.count == 0 && !q.closed {
+		// We wait here until the queue has an item
+		start = startWait(q.metrics, start)
+		q.notEmpty.Wait()
+	}
+	stopWait(q.metrics, start, false)
+
+	// Critical section after wait released and predicate is false
+	var item, err = q.tryPop()
+	q.lock.Unlock()
+
+	return item, err
+}
+
+// Puts an element to the tail of the queue.
+// It blocks the current goroutine if the queue is Full until notified.
+// Returns the error of the store if it refuses the item
+func (q *BlockingQueue[T]) Put(item T) (bool, error) {
+	data, err := q.check(item)
+	if err != nil {
+		return false, err
+	}
+
+	if q.fair {
+		return q.fairPut(context.Background(), item, data)
+	}
+
+	q.lock.Lock()
+
+	var start time.Time
+	for q.remaining() == 0 && !q.closed {
+		// We wait here until the queue has an empty slot
+		start = startWait(q.metrics, start)
+		q.notFull.Wait()
+	}
+	stopWait(q.metrics, start, true)
+
+	// Critical section after wait released and predicate is false
+	res, err := q.tryPush(item, data)
 	q.lock.Unlock()
+
+	return res, err
 }
 
 // Takes an element from the head of the queue.
 // It blocks the current goroutine if the queue is Empty until notified
-func (q *BlockingQueue) Get() (interface{}, error) {
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *BlockingQueue[T]) GetContext(ctx context.Context) (T, error) {
+	if q.fair {
+		return q.fairGet(ctx)
+	}
+
 	q.lock.Lock()
 
-	for q.count == 0 {
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, func() {
+		q.lock.Lock()
+		q.notEmpty.Broadcast()
+		q.lock.Unlock()
+	})
+
+	var start time.Time
+	for q.count == 0 && !q.closed {
+		if err := ctx.Err(); err != nil {
+			stopWait(q.metrics, start, false)
+			q.lock.Unlock()
+			stop()
+
+			var zero T
+			return zero, err
+		}
 		// We wait here until the queue has an item
+		start = startWait(q.metrics, start)
 		q.notEmpty.Wait()
 	}
+	stopWait(q.metrics, start, false)
 
 	// Critical section after wait released and predicate is false
 	var item, err = q.tryPop()
 	q.lock.Unlock()
+	stop()
 
 	return item, err
 }
 
 // Puts an element to the tail of the queue.
 // It blocks the current goroutine if the queue is Full until notified
-func (q *BlockingQueue) Put(item interface{}) (bool, error) {
-	if item == nil {
-		panic("Null item")
+// or until ctx is done, in which case it returns ctx.Err().
+// Returns the error of the store if it refuses the item
+func (q *BlockingQueue[T]) PutContext(ctx context.Context, item T) (bool, error) {
+	data, err := q.check(item)
+	if err != nil {
+		return false, err
+	}
+
+	if q.fair {
+		return q.fairPut(ctx, item, data)
 	}
 
 	q.lock.Lock()
 
-	for q.count == q.store.Size() {
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, func() {
+		q.lock.Lock()
+		q.notFull.Broadcast()
+		q.lock.Unlock()
+	})
+
+	var start time.Time
+	for q.remaining() == 0 && !q.closed {
+		if err := ctx.Err(); err != nil {
+			stopWait(q.metrics, start, true)
+			q.lock.Unlock()
+			stop()
+
+			return false, err
+		}
 		// We wait here until the queue has an empty slot
+		start = startWait(q.metrics, start)
 		q.notFull.Wait()
 	}
+	stopWait(q.metrics, start, true)
+
 	// Critical section after wait released and predicate is false
-	var res, err = q.tryPush(item)
+	res, err := q.tryPush(item, data)
 	q.lock.Unlock()
+	stop()
 
 	return res, err
 }
+
+// Takes an element from the head of the queue, waiting up to timeout
+// for one to become available. Returns ErrorEmpty if the timeout elapses
+func (q *BlockingQueue[T]) Poll(timeout time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	item, err := q.GetContext(ctx)
+	cancel()
+
+	if err == context.DeadlineExceeded {
+		err = ErrorEmpty
+		q.reject(err)
+	}
+
+	return item, err
+}
+
+// Puts an element to the tail of the queue, waiting up to timeout
+// for space to become available. Returns ErrorFull if the timeout elapses
+func (q *BlockingQueue[T]) OfferTimeout(item T, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	res, err := q.PutContext(ctx, item)
+	cancel()
+
+	if err == context.DeadlineExceeded {
+		q.reject(ErrorFull)
+		return false, ErrorFull
+	}
+
+	return res, err
+}
+
+// Closes the queue. Further Put, Offer and Push calls are rejected with
+// ErrorClosed while the remaining elements can still be taken. Once the
+// queue is drained, Get returns ErrorClosed and the store is closed if it
+// is an io.Closer, so it keeps recording the elements taken meanwhile.
+// Wakes up every waiter.
+// Closing an already closed queue has no effect
+func (q *BlockingQueue[T]) Close() {
+	q.lock.Lock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+		q.notEmpty.Broadcast()
+		q.notFull.Broadcast()
+
+		if q.fair {
+			q.releaseWaiters()
+		}
+		q.closeStore()
+	}
+	q.lock.Unlock()
+}
+
+// Reports whether the queue has been closed
+func (q *BlockingQueue[T]) IsClosed() bool {
+	q.lock.Lock()
+	res := q.closed
+	q.lock.Unlock()
+
+	return res
+}
+
+// Returns a channel that is closed when the queue gets closed
+func (q *BlockingQueue[T]) Done() <-chan struct{} {
+	return q.done
+}
+
+// Returns the first error of the store if it reports any,
+// like a write failure of a persistent queue
+func (q *BlockingQueue[T]) Err() error {
+	q.lock.Lock()
+
+	var err error
+	if store, ok := q.store.(interface{ Err() error }); ok {
+		err = store.Err()
+	}
+	q.lock.Unlock()
+
+	return err
+}
+
+// Removes up to max elements from the head of the queue and copies them
+// into dst, returning how many were removed. At most len(dst) elements
+// are removed. Does not block the current goroutine
+func (q *BlockingQueue[T]) DrainTo(dst []T, max int) int {
+	if max > len(dst) {
+		max = len(dst)
+	}
+
+	q.lock.Lock()
+
+	var n = 0
+	for n < max && q.count > 0 {
+		dst[n] = q.dequeue()
+		n += 1
+	}
+
+	q.signalNotFull(n)
+	q.lock.Unlock()
+
+	return n
+}
+
+// Removes up to max elements from the head of the queue and offers them
+// to other, stopping at the first element other does not accept.
+// Returns how many were transferred. Does not block the current goroutine.
+// Another BlockingQueue is filled while holding the locks of both queues.
+// Other queues are offered each element without holding the lock, so an
+// element another consumer takes meanwhile ends up in both queues
+func (q *BlockingQueue[T]) DrainToQueue(other Interface[T], max int) int {
+	if other == Interface[T](q) {
+		panic("Drain to self")
+	}
+
+	if dst, ok := other.(*BlockingQueue[T]); ok {
+		return q.drainToBlockingQueue(dst, max)
+	}
+
+	var n = 0
+	for n < max {
+		q.lock.Lock()
+		if q.count == 0 {
+			q.lock.Unlock()
+			break
+		}
+		var item, taken = q.store.Get(q.readIndex), q.taken
+		q.lock.Unlock()
+
+		if !other.Offer(item) {
+			break
+		}
+		n += 1
+
+		q.lock.Lock()
+		if q.taken != taken {
+			// The head was taken meanwhile, leave what is now there
+			q.lock.Unlock()
+			break
+		}
+		q.pop()
+		q.lock.Unlock()
+	}
+
+	return n
+}
+
+// Moves elements to other while holding both locks, taken in address
+// order so queues draining into each other cannot deadlock
+func (q *BlockingQueue[T]) drainToBlockingQueue(other *BlockingQueue[T], max int) int {
+	var first, second = q.lock, other.lock
+	if uintptr(unsafe.Pointer(other)) < uintptr(unsafe.Pointer(q)) {
+		first, second = second, first
+	}
+	first.Lock()
+	second.Lock()
+
+	var n = 0
+	for n < max && q.count > 0 {
+		var item = q.store.Get(q.readIndex)
+		data, err := other.check(item)
+		if err != nil {
+			break
+		}
+		if res, _ := other.tryPush(item, data); !res {
+			break
+		}
+		q.dequeue()
+		n += 1
+	}
+
+	q.signalNotFull(n)
+	second.Unlock()
+	first.Unlock()
+
+	return n
+}
+
+// Puts all the elements to the tail of the queue in order.
+// It blocks the current goroutine whenever the queue is Full until notified.
+// Returns how many elements were put, with ErrorClosed if the queue got
+// closed before all of them were. Stops before the first element the
+// store refuses, returning its error
+func (q *BlockingQueue[T]) PutAll(items []T) (int, error) {
+	data, refused := q.checkAll(items)
+	items = items[:len(data)]
+
+	if q.fair {
+		// Each element has to wait for its turn
+		for n, item := range items {
+			if _, err := q.fairPut(context.Background(), item, data[n]); err != nil {
+				return n, err
+			}
+		}
+
+		return len(items), refused
+	}
+
+	q.lock.Lock()
+
+	var n, pending = 0, 0
+	for n < len(items) {
+		var start time.Time
+		for q.remaining() == 0 && !q.closed {
+			// Let readers free some slots before we wait for them
+			q.signalNotEmpty(pending)
+			pending = 0
+			start = startWait(q.metrics, start)
+			q.notFull.Wait()
+		}
+		stopWait(q.metrics, start, true)
+
+		if q.closed {
+			break
+		}
+		q.enqueue(items[n], data[n])
+		n += 1
+		pending += 1
+	}
+	q.signalNotEmpty(pending)
+	q.lock.Unlock()
+
+	if n < len(items) {
+		return n, ErrorClosed
+	}
+
+	return n, refused
+}
+
+// Inserts as many of the elements as possible at the tail of this queue
+// without exceeding the queue's capacity, returning how many were accepted.
+// Stops before the first element the store refuses.
+// Does not block the current goroutine
+func (q *BlockingQueue[T]) OfferAll(items []T) int {
+	data, _ := q.checkAll(items)
+	items = items[:len(data)]
+
+	q.lock.Lock()
+
+	var n = 0
+	for !q.closed && n < len(items) && q.remaining() > 0 {
+		q.enqueue(items[n], data[n])
+		n += 1
+	}
+	if !q.closed && n < len(items) {
+		q.reject(ErrorFull)
+	}
+	q.signalNotEmpty(n)
+	q.lock.Unlock()
+
+	return n
+}
+
+// Inserts all the elements at the tail of this queue only if there is
+// capacity for every one of them, returning true upon success and
+// false otherwise, or if the store refuses any of them, in which case
+// none is inserted.
+// Does not block the current goroutine
+func (q *BlockingQueue[T]) OfferBatch(items []T) bool {
+	data, err := q.checkAll(items)
+	if err != nil {
+		return false
+	}
+
+	return q.offerBatch(items, data)
+}
+
+// Inserts all the checked elements only if there is capacity for every one
+// of them, data holding what the store needs for each of them
+func (q *BlockingQueue[T]) offerBatch(items []T, data [][]byte) (res bool) {
+	q.lock.Lock()
+
+	if !q.closed && uint64(len(items)) <= q.remaining() {
+		for n, item := range items {
+			q.enqueue(item, data[n])
+		}
+		q.signalNotEmpty(len(items))
+		res = true
+	} else if !q.closed {
+		q.reject(ErrorFull)
+	}
+	q.lock.Unlock()
+
+	return
+}
@@ -0,0 +1,74 @@
+package blockingQueues
+
+// Returns a channel whose values are Put to the tail of the queue by a
+// pump goroutine, so producers can select on it. Sends block while the
+// queue is Full, with the pump holding one extra value. The pump stops once
+// the queue is closed and later sends are never received, so select on
+// Done alongside. Closing the channel stops the pump and leaves the queue
+// open. Values the store refuses are dropped, and so is the value the pump
+// holds when the queue gets closed, although its send succeeded.
+// Every call returns the same channel
+func (q *BlockingQueue[T]) In() chan<- T {
+	q.lock.Lock()
+
+	if q.in == nil {
+		q.in = make(chan T)
+		go q.pumpIn(q.in)
+	}
+	res := q.in
+	q.lock.Unlock()
+
+	return res
+}
+
+// Returns a channel receiving the elements taken from the head of the
+// queue by a pump goroutine, so consumers can select on it. The pump
+// holds one element taken from the queue until it is received. That
+// element is left out of Size and snapshots, and persistent stores have
+// already recorded it as taken, so it is lost if the process stops
+// before it is received. The channel is closed once the queue is closed
+// and drained.
+// Every call returns the same channel
+func (q *BlockingQueue[T]) Out() <-chan T {
+	q.lock.Lock()
+
+	if q.out == nil {
+		q.out = make(chan T)
+		go q.pumpOut(q.out)
+	}
+	res := q.out
+	q.lock.Unlock()
+
+	return res
+}
+
+// Puts the values received from in until in or the queue gets closed
+func (q *BlockingQueue[T]) pumpIn(in <-chan T) {
+	for {
+		select {
+		case item, ok := <-in:
+			if !ok {
+				// The producers closed the channel
+				return
+			}
+			if _, err := q.Put(item); err == ErrorClosed {
+				// Closed while we were holding the item
+				return
+			}
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// Sends the elements taken from the queue to out until it is closed and drained
+func (q *BlockingQueue[T]) pumpOut(out chan<- T) {
+	for {
+		item, err := q.Get()
+		if err != nil {
+			close(out)
+			return
+		}
+		out <- item
+	}
+}
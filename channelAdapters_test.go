@@ -0,0 +1,139 @@
+package blockingQueues
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type ChannelAdaptersSuite struct {
+	queue *BlockingQueue[interface{}]
+}
+
+var _ = Suite(&ChannelAdaptersSuite{})
+
+func (s *ChannelAdaptersSuite) SetUpTest(c *C) {
+	s.queue, _ = NewArrayBlockingQueue(4)
+}
+
+func (s *ChannelAdaptersSuite) TestSameChannel(c *C) {
+	c.Assert(s.queue.In(), Equals, s.queue.In())
+	c.Assert(s.queue.Out(), Equals, s.queue.Out())
+}
+
+func (s *ChannelAdaptersSuite) TestIn(c *C) {
+	for i := 0; i < 4; i++ {
+		s.queue.In() <- i
+	}
+
+	for i := 0; i < 4; i++ {
+		item, err := s.queue.Get()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, i)
+	}
+}
+
+func (s *ChannelAdaptersSuite) TestInRespectsCapacity(c *C) {
+	sent := 0
+	timeout := time.After(50 * time.Millisecond)
+
+loop:
+	for {
+		select {
+		case s.queue.In() <- sent:
+			sent++
+		case <-timeout:
+			break loop
+		}
+	}
+
+	// The pump holds one extra value while the queue is full
+	c.Assert(sent, Equals, 5)
+	c.Assert(s.queue.Size(), Equals, uint64(4))
+}
+
+func (s *ChannelAdaptersSuite) TestInStopsOnClose(c *C) {
+	s.queue.In() <- 1
+	item, _ := s.queue.Get()
+	c.Assert(item, Equals, 1)
+
+	s.queue.Close()
+
+	select {
+	case s.queue.In() <- 2:
+		// The pump may have been selecting on in when the queue closed
+	case <-s.queue.Done():
+	}
+
+	_, err := s.queue.Get()
+	c.Assert(err, Equals, ErrorClosed)
+}
+
+func (s *ChannelAdaptersSuite) TestClosingIn(c *C) {
+	in := s.queue.In()
+	in <- 1
+	close(in)
+
+	// Give the pump time to receive from the closed channel
+	time.Sleep(20 * time.Millisecond)
+	c.Assert(s.queue.Size(), Equals, uint64(1))
+	c.Assert(s.queue.IsClosed(), Equals, false)
+
+	item, _ := s.queue.Get()
+	c.Assert(item, Equals, 1)
+}
+
+func (s *ChannelAdaptersSuite) TestOut(c *C) {
+	for i := 0; i < 4; i++ {
+		s.queue.Put(i)
+	}
+
+	for i := 0; i < 4; i++ {
+		select {
+		case item := <-s.queue.Out():
+			c.Assert(item, Equals, i)
+		case <-time.After(time.Second):
+			c.Fatal("no item received")
+		}
+	}
+
+	select {
+	case <-s.queue.Out():
+		c.Error("received from an empty queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func (s *ChannelAdaptersSuite) TestOutClosesAfterDrain(c *C) {
+	s.queue.Put(1)
+	s.queue.Put(2)
+	s.queue.Close()
+
+	var items []interface{}
+	for item := range s.queue.Out() {
+		items = append(items, item)
+	}
+
+	c.Assert(items, DeepEquals, []interface{}{1, 2})
+}
+
+func (s *ChannelAdaptersSuite) TestPipe(c *C) {
+	dst, _ := NewArrayBlockingQueue(4)
+
+	go func() {
+		for item := range s.queue.Out() {
+			dst.In() <- item
+		}
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.queue.Put(i)
+		}
+		s.queue.Close()
+	}()
+
+	for i := 0; i < 100; i++ {
+		item, _ := dst.Get()
+		c.Assert(item, Equals, i)
+	}
+}
@@ -0,0 +1,47 @@
+package blockingQueues
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec encoding every item on its own with encoding/gob.
+// For queues of interface{} the concrete types must be registered
+// with gob.Register
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(item T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&item); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var item T
+	var err = gob.NewDecoder(bytes.NewReader(data)).Decode(&item)
+
+	return item, err
+}
+
+// Codec encoding items with encoding/json.
+// For queues of interface{} items are decoded as generic JSON values,
+// like float64 for numbers
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(item T) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var item T
+	var err = json.Unmarshal(data, &item)
+
+	return item, err
+}
+
+var _ Codec[interface{}] = GobCodec[interface{}]{}
+var _ Codec[interface{}] = JSONCodec[interface{}]{}
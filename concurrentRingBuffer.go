@@ -1,63 +1,343 @@
 package blockingQueues
 
 import (
+	"context"
 	"runtime"
 	"sync/atomic"
+	"time"
 )
 
-type ConcurrentRingBuffer struct {
+// Largest capacity of a ConcurrentRingBuffer
+const maxRingBufferCapacity = 1 << 30
+
+// Slot of a ConcurrentRingBuffer. Its sequence tells who may use it:
+// the producer of position seq, or the consumer of position seq-1
+// once the item is written. The item is boxed so Peek and Snapshot can
+// load it while a consumer takes it
+type ringSlot[T any] struct {
+	seq  uint64
+	item atomic.Pointer[T]
+}
+
+/**
+ * ConcurrentRingBuffer is a bounded lock-free multi-producer, multi-consumer
+ * queue (Vyukov's bounded MPMC queue). Producers and consumers claim positions
+ * with a CAS and hand the slots over through their sequence numbers, so a slow
+ * producer only delays the consumer of its own slot. Elements are boxed so
+ * Peek and Snapshot read them without holding up producers or consumers.
+ * Blocking operations yield the goroutine while they wait instead of sleeping
+ */
+type ConcurrentRingBuffer[T any] struct {
 	// The padding members
 	// below are here to ensure each item is on a separate cache line.
-	pad1               [8]uint64
-	lastCommittedIndex uint64
-	pad2               [8]uint64
-	writeIndex         uint64
-	pad3               [8]uint64
-	readIndex          uint64
-	pad4               [8]uint64
-	store              []interface{} // This will gain speed if its a specific type
-	pad5               [8]uint64
-}
-
-func NewConcurrentRingBuffer(capacity uint64) *ConcurrentRingBuffer {
-	return &ConcurrentRingBuffer{
-		lastCommittedIndex: 0,
-		writeIndex:         1,
-		readIndex:          1,
-		store:              make([]interface{}, capacity),
-	}
-}
-
-func (q *ConcurrentRingBuffer) Put(value interface{}) (bool, error) {
-	// Load next write index
-	var nextWriteIndex = atomic.AddUint64(&q.writeIndex, 1) - 1
-	var mask = uint64(cap(q.store) - 1)
-
-	// Wait for reader to catch up as we don't want to go too far on the writes
-	for nextWriteIndex > (q.readIndex + mask - 1) {
-		// This will block the writer if the store is full
-		runtime.Gosched()
+	pad1       [8]uint64
+	enqueuePos uint64
+	pad2       [8]uint64
+	dequeuePos uint64
+	pad3       [8]uint64
+	slots      []ringSlot[T]
+	mask       uint64
+	metrics    atomic.Pointer[Metrics]
+	pad4       [8]uint64
+}
+
+// Creates an ConcurrentRingBuffer holding capacity elements, rounded up
+// to a power of two of at least 2.
+// returns an error if the capacity is less than 1 or too large
+func NewConcurrentRingBuffer(capacity uint64) (*ConcurrentRingBuffer[interface{}], error) {
+	return NewConcurrentRingBufferOf[interface{}](capacity)
+}
+
+// Creates an ConcurrentRingBuffer of T holding capacity elements, rounded up
+// to a power of two of at least 2.
+// returns an error if the capacity is less than 1 or too large
+func NewConcurrentRingBufferOf[T any](capacity uint64) (*ConcurrentRingBuffer[T], error) {
+	if capacity < 1 || capacity > maxRingBufferCapacity {
+		return nil, ErrorCapacity
+	}
+
+	// A single slot could not tell a written item from a free slot of the next lap
+	var size = uint64(2)
+	for size < capacity {
+		size <<= 1
+	}
+
+	var slots = make([]ringSlot[T], size)
+	for i := range slots {
+		slots[i].seq = uint64(i)
+	}
+
+	return &ConcurrentRingBuffer[T]{
+		slots: slots,
+		mask:  size - 1,
+	}, nil
+}
+
+var _ Interface[interface{}] = (*ConcurrentRingBuffer[interface{}])(nil)
+
+// Writes the boxed item at the tail if there is a free slot
+func (q *ConcurrentRingBuffer[T]) tryEnqueue(item *T) bool {
+	var pos = atomic.LoadUint64(&q.enqueuePos)
+
+	for {
+		var slot = &q.slots[pos&q.mask]
+		var diff = int64(atomic.LoadUint64(&slot.seq) - pos)
+
+		if diff == 0 {
+			// The slot is free, claim the position
+			if atomic.CompareAndSwapUint64(&q.enqueuePos, pos, pos+1) {
+				slot.item.Store(item)
+				atomic.StoreUint64(&slot.seq, pos+1)
+
+				return true
+			}
+		} else if diff < 0 {
+			// The consumer of the previous lap did not take the item yet
+			return false
+		}
+		pos = atomic.LoadUint64(&q.enqueuePos)
+	}
+}
+
+// Takes the item at the head if one is written
+func (q *ConcurrentRingBuffer[T]) tryDequeue() (T, bool) {
+	var pos = atomic.LoadUint64(&q.dequeuePos)
+
+	for {
+		var slot = &q.slots[pos&q.mask]
+		var diff = int64(atomic.LoadUint64(&slot.seq) - (pos + 1))
+
+		if diff == 0 {
+			// The item is written, claim the position
+			if atomic.CompareAndSwapUint64(&q.dequeuePos, pos, pos+1) {
+				var item = *slot.item.Load()
+				slot.item.Store(nil)
+
+				// Free the slot for the producer of the next lap
+				atomic.StoreUint64(&slot.seq, pos+q.mask+1)
+
+				return item, true
+			}
+		} else if diff < 0 {
+			// The producer did not write the item yet
+			var zero T
+			return zero, false
+		}
+		pos = atomic.LoadUint64(&q.dequeuePos)
+	}
+}
+
+// Copies the item at pos if it is written and not taken.
+// Never holds the slot, the item is only returned if the sequence
+// shows it was still the one of pos once loaded
+func (q *ConcurrentRingBuffer[T]) peekAt(pos uint64) (T, bool) {
+	var slot = &q.slots[pos&q.mask]
+	var zero T
+
+	if atomic.LoadUint64(&slot.seq) != pos+1 {
+		return zero, false
+	}
+
+	// A producer of the next lap moves the sequence past pos+1 first
+	var item = slot.item.Load()
+	if item == nil || atomic.LoadUint64(&slot.seq) != pos+1 {
+		return zero, false
 	}
 
-	// Write the item into it's slot
-	q.store[nextWriteIndex&mask] = value
+	return *item, true
+}
+
+func (q *ConcurrentRingBuffer[T]) Put(value T) (bool, error) {
+	return q.PutContext(context.Background(), value)
+}
+
+func (q *ConcurrentRingBuffer[T]) Get() (T, error) {
+	return q.GetContext(context.Background())
+}
+
+// Takes an element from the head of the buffer, waiting up to timeout
+// for one to be written. Returns ErrorEmpty if the timeout elapses
+func (q *ConcurrentRingBuffer[T]) Poll(timeout time.Duration) (T, error) {
+	return q.take(context.Background(), time.Now().Add(timeout))
+}
+
+// Takes an element from the head of the buffer.
+// It yields the current goroutine while the buffer is Empty
+// until ctx is done, in which case it returns ctx.Err()
+func (q *ConcurrentRingBuffer[T]) GetContext(ctx context.Context) (T, error) {
+	return q.take(ctx, time.Time{})
+}
+
+// Pops an element from the head of the buffer.
+// Does not block the current goroutine
+func (q *ConcurrentRingBuffer[T]) Pop() (T, error) {
+	var metrics = q.Metrics()
+
+	item, ok := q.tryDequeue()
+	if !ok {
+		if metrics != nil {
+			metrics.OnReject(ErrorEmpty)
+		}
 
-	// Increment the lastCommittedIndex so the item is available for reading
-	for !atomic.CompareAndSwapUint64(&q.lastCommittedIndex, nextWriteIndex-1, nextWriteIndex) {
+		return item, ErrorEmpty
+	}
+
+	if metrics != nil {
+		metrics.OnDequeue(q.Size())
+	}
+
+	return item, nil
+}
+
+// Waits for an element until ctx is done or, unless zero,
+// deadline passes, in which case it returns ErrorEmpty
+func (q *ConcurrentRingBuffer[T]) take(ctx context.Context, deadline time.Time) (T, error) {
+	var metrics = q.Metrics()
+	var start time.Time
+
+	for {
+		if item, ok := q.tryDequeue(); ok {
+			stopWait(metrics, start, false)
+			if metrics != nil {
+				metrics.OnDequeue(q.Size())
+			}
+
+			return item, nil
+		}
+
+		var zero T
+
+		if err := ctx.Err(); err != nil {
+			stopWait(metrics, start, false)
+			return zero, err
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			stopWait(metrics, start, false)
+			if metrics != nil {
+				metrics.OnReject(ErrorEmpty)
+			}
+
+			return zero, ErrorEmpty
+		}
+		start = startWait(metrics, start)
 		runtime.Gosched()
 	}
+}
+
+// Puts an element to the tail of the buffer, waiting up to timeout
+// for a slot to be freed. Returns ErrorFull if the timeout elapses
+func (q *ConcurrentRingBuffer[T]) OfferTimeout(value T, timeout time.Duration) (bool, error) {
+	return q.give(context.Background(), time.Now().Add(timeout), value)
+}
+
+// Puts an element to the tail of the buffer.
+// It yields the current goroutine while the buffer is Full
+// until ctx is done, in which case it returns ctx.Err()
+func (q *ConcurrentRingBuffer[T]) PutContext(ctx context.Context, value T) (bool, error) {
+	return q.give(ctx, time.Time{}, value)
+}
+
+// Pushes the specified element at the tail of the buffer.
+// Does not block the current goroutine
+func (q *ConcurrentRingBuffer[T]) Push(value T) (bool, error) {
+	var metrics = q.Metrics()
+
+	if !q.tryEnqueue(&value) {
+		if metrics != nil {
+			metrics.OnReject(ErrorFull)
+		}
+
+		return false, ErrorFull
+	}
+
+	if metrics != nil {
+		metrics.OnEnqueue(q.Size())
+	}
 
 	return true, nil
 }
 
-func (q *ConcurrentRingBuffer) Get() (interface{}, error) {
-	// Load next read index
-	var nextReadIndex = atomic.AddUint64(&q.readIndex, 1) - 1
-	var mask = uint64(cap(q.store) - 1)
+// Inserts the specified element at the tail of the buffer if it is possible to
+// do so immediately, returning true upon success and false if the buffer is full.
+// Does not block the current goroutine
+func (q *ConcurrentRingBuffer[T]) Offer(value T) bool {
+	res, _ := q.Push(value)
+	return res
+}
+
+// Waits for a free slot until ctx is done or, unless zero,
+// deadline passes, in which case it returns ErrorFull
+func (q *ConcurrentRingBuffer[T]) give(ctx context.Context, deadline time.Time, value T) (bool, error) {
+	var metrics = q.Metrics()
+	var start time.Time
+	var item = &value
+
+	for !q.tryEnqueue(item) {
+		if err := ctx.Err(); err != nil {
+			stopWait(metrics, start, true)
+			return false, err
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			stopWait(metrics, start, true)
+			if metrics != nil {
+				metrics.OnReject(ErrorFull)
+			}
 
-	// If reader has out-run writer, wait for a value to be committed
-	for nextReadIndex > q.lastCommittedIndex {
+			return false, ErrorFull
+		}
+		start = startWait(metrics, start)
 		runtime.Gosched()
 	}
-	return q.store[nextReadIndex&mask], nil
+	stopWait(metrics, start, true)
+
+	if metrics != nil {
+		metrics.OnEnqueue(q.Size())
+	}
+
+	return true, nil
+}
+
+// Size returns the number of claimed and not yet taken elements, is concurrent safe
+func (q *ConcurrentRingBuffer[T]) Size() uint64 {
+	// Consumers never pass the producers, so loading the head first
+	// keeps the difference positive
+	var dequeuePos = atomic.LoadUint64(&q.dequeuePos)
+	var size = atomic.LoadUint64(&q.enqueuePos) - dequeuePos
+
+	return min(size, q.mask+1)
+}
+
+// Capacity returns this current elements remaining capacity, is concurrent safe
+func (q *ConcurrentRingBuffer[T]) Capacity() uint64 {
+	return q.mask + 1 - q.Size()
+}
+
+func (q *ConcurrentRingBuffer[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Takes the written elements until the buffer is empty.
+// Elements put concurrently may be taken too
+func (q *ConcurrentRingBuffer[T]) Clear() {
+	for {
+		if _, ok := q.tryDequeue(); !ok {
+			return
+		}
+	}
+}
+
+// Just attempts to return the head element of the buffer
+func (q *ConcurrentRingBuffer[T]) Peek() T {
+	for {
+		var pos = atomic.LoadUint64(&q.dequeuePos)
+		if item, ok := q.peekAt(pos); ok {
+			return item
+		}
+
+		// Start over if a consumer took the element meanwhile
+		if atomic.LoadUint64(&q.dequeuePos) == pos {
+			var zero T
+			return zero
+		}
+	}
 }
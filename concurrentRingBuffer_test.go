@@ -1,17 +1,365 @@
 package blockingQueues
 
 import (
+	"context"
 	. "gopkg.in/check.v1"
+	"runtime"
+	"sync"
+	"time"
 )
 
 type ConcurrentRingBufferSuite struct {
-	queue *ConcurrentRingBuffer
+	queue *ConcurrentRingBuffer[interface{}]
 }
 
 var _ = Suite(&ConcurrentRingBufferSuite{})
 
 func (s *ConcurrentRingBufferSuite) SetUpTest(c *C) {
-	s.queue = NewConcurrentRingBuffer(4096)
+	s.queue, _ = NewConcurrentRingBuffer(4096)
+}
+
+func (s *ConcurrentRingBufferSuite) TestPollTimeout(c *C) {
+	item, err := s.queue.Poll(10 * time.Millisecond)
+	c.Assert(item, IsNil)
+	c.Assert(err, ErrorMatches, "ERROR_EMPTY: attempt to Get while Queue is Empty")
+
+	s.queue.Put(1)
+	s.queue.Put(2)
+
+	item, err = s.queue.Poll(10 * time.Millisecond)
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+
+	item, err = s.queue.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 2)
+}
+
+func (s *ConcurrentRingBufferSuite) TestOfferTimeout(c *C) {
+	q, _ := NewConcurrentRingBuffer(4)
+
+	for i := 0; i < 4; i += 1 {
+		res, err := q.OfferTimeout(i, 10*time.Millisecond)
+		c.Assert(res, Equals, true)
+		c.Assert(err, IsNil)
+	}
+
+	res, err := q.OfferTimeout(4, 10*time.Millisecond)
+	c.Assert(res, Equals, false)
+	c.Assert(err, ErrorMatches, "ERROR_FULL: attempt to Put while Queue is Full")
+
+	item, _ := q.Get()
+	c.Assert(item, Equals, 0)
+
+	res, err = q.OfferTimeout(4, 10*time.Millisecond)
+	c.Assert(res, Equals, true)
+	c.Assert(err, IsNil)
+}
+
+func (s *ConcurrentRingBufferSuite) TestTyped(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](4)
+
+	q.Put(1)
+	q.Put(2)
+
+	item, err := q.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+
+	item, err = q.Poll(0)
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 2)
+
+	item, err = q.Poll(0)
+	c.Assert(item, Equals, 0)
+	c.Assert(err, Equals, ErrorEmpty)
+}
+
+func (s *ConcurrentRingBufferSuite) TestPushPop(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](4)
+	c.Assert(q.IsEmpty(), Equals, true)
+	c.Assert(q.Capacity(), Equals, uint64(4))
+
+	for i := 0; i < 4; i += 1 {
+		c.Assert(q.Offer(i), Equals, true)
+	}
+	c.Assert(q.Size(), Equals, uint64(4))
+	c.Assert(q.Capacity(), Equals, uint64(0))
+
+	res, err := q.Push(4)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorFull)
+
+	c.Assert(q.Peek(), Equals, 0)
+
+	item, err := q.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 0)
+	c.Assert(q.Peek(), Equals, 1)
+	c.Assert(q.Size(), Equals, uint64(3))
+}
+
+func (s *ConcurrentRingBufferSuite) TestPopEmpty(c *C) {
+	item, err := s.queue.Pop()
+	c.Assert(item, IsNil)
+	c.Assert(err, Equals, ErrorEmpty)
+	c.Assert(s.queue.Peek(), IsNil)
+}
+
+func (s *ConcurrentRingBufferSuite) TestClear(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](4)
+	q.Put(1)
+	q.Put(2)
+	q.Clear()
+
+	c.Assert(q.IsEmpty(), Equals, true)
+	c.Assert(q.Capacity(), Equals, uint64(4))
+
+	_, err := q.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+
+	q.Put(3)
+	item, _ := q.Get()
+	c.Assert(item, Equals, 3)
+}
+
+func (s *ConcurrentRingBufferSuite) TestContext(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	_, err := q.GetContext(ctx)
+	cancel()
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	for i := 1; i <= 2; i += 1 {
+		res, err := q.PutContext(context.Background(), i)
+		c.Assert(res, Equals, true)
+		c.Assert(err, IsNil)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	res, err := q.PutContext(ctx, 3)
+	cancel()
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	item, err := q.GetContext(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+}
+
+func (s *ConcurrentRingBufferSuite) TestCapacity(c *C) {
+	for _, capacity := range [][2]uint64{{1, 2}, {2, 2}, {3, 4}, {5, 8}, {1000, 1024}, {1024, 1024}} {
+		q, err := NewConcurrentRingBufferOf[struct{}](capacity[0])
+		c.Assert(err, IsNil)
+		c.Assert(q.Capacity(), Equals, capacity[1])
+	}
+
+	for _, capacity := range []uint64{0, 1<<30 + 1, 1 << 63} {
+		_, err := NewConcurrentRingBuffer(capacity)
+		c.Assert(err, Equals, ErrorCapacity)
+	}
+}
+
+func (s *ConcurrentRingBufferSuite) TestWrapAround(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](4)
+
+	for i := 0; i < 100; i += 1 {
+		c.Assert(q.Offer(i), Equals, true)
+		c.Assert(q.Offer(i+1000), Equals, true)
+
+		item, _ := q.Pop()
+		c.Assert(item, Equals, i)
+		item, _ = q.Pop()
+		c.Assert(item, Equals, i+1000)
+	}
+	c.Assert(q.IsEmpty(), Equals, true)
+}
+
+// Every item is taken exactly once and the items of each producer
+// are taken in the order it put them
+func (s *ConcurrentRingBufferSuite) TestStress(c *C) {
+	const producers, consumers, items = 8, 8, 5000
+
+	q, _ := NewConcurrentRingBufferOf[int](16)
+	var taken = make([][]int, consumers)
+	var wg sync.WaitGroup
+
+	for p := 0; p < producers; p += 1 {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < items; i += 1 {
+				q.Put(p*items + i)
+			}
+		}(p)
+	}
+
+	for r := 0; r < consumers; r += 1 {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := 0; i < producers*items/consumers; i += 1 {
+				item, _ := q.Get()
+				taken[r] = append(taken[r], item)
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	var seen = make([]bool, producers*items)
+	for _, got := range taken {
+		var last = make(map[int]int)
+		for _, item := range got {
+			c.Assert(seen[item], Equals, false)
+			seen[item] = true
+
+			if prev, ok := last[item/items]; ok {
+				c.Assert(prev < item, Equals, true)
+			}
+			last[item/items] = item
+		}
+	}
+	for _, ok := range seen {
+		c.Assert(ok, Equals, true)
+	}
+	c.Assert(q.IsEmpty(), Equals, true)
+}
+
+// Pop, Clear and Drain never miss an element Peek or Snapshot is reading
+func (s *ConcurrentRingBufferSuite) TestConsumersWithPeekers(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](8)
+	var done = make(chan struct{})
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				q.Peek()
+				q.Snapshot()
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	for round := 0; round < 2000; round += 1 {
+		for i := 0; i < 8; i += 1 {
+			q.Put(i)
+		}
+
+		switch round % 3 {
+		case 0:
+			for q.Size() > 0 {
+				_, err := q.Pop()
+				c.Assert(err, IsNil)
+			}
+		case 1:
+			q.Clear()
+		case 2:
+			var n = 0
+			for range q.Drain() {
+				n += 1
+			}
+			c.Assert(n, Equals, 8)
+		}
+		c.Assert(q.Size(), Equals, uint64(0))
+	}
+	close(done)
+	wg.Wait()
+}
+
+// Readers that do not take items run alongside producers and consumers
+func (s *ConcurrentRingBufferSuite) TestStressReaders(c *C) {
+	const items = 20000
+
+	q, _ := NewConcurrentRingBufferOf[int](8)
+	var done = make(chan struct{})
+	var wg sync.WaitGroup
+
+	for r := 0; r < 4; r += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				c.Check(q.Size() <= 8, Equals, true)
+				c.Check(len(q.Snapshot()) <= 8, Equals, true)
+				q.Peek()
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	go func() {
+		for i := 1; i <= items; i += 1 {
+			q.Put(i)
+		}
+	}()
+
+	var last = 0
+	for i := 0; i < items; i += 1 {
+		item, err := q.Get()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, last+1)
+		last = item
+	}
+	close(done)
+	wg.Wait()
+}
+
+// A producer blocked on a full buffer does not keep the others from
+// filling slots freed later
+func (s *ConcurrentRingBufferSuite) TestStressTimeouts(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](4)
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var accepted, received = 0, 0
+
+	for p := 0; p < 4; p += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i += 1 {
+				if res, _ := q.OfferTimeout(i, time.Microsecond); res {
+					lock.Lock()
+					accepted += 1
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	for r := 0; r < 4; r += 1 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i += 1 {
+				if _, err := q.Poll(time.Microsecond); err == nil {
+					lock.Lock()
+					received += 1
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for range q.Drain() {
+		received += 1
+	}
+	c.Assert(received, Equals, accepted)
 }
 
 func (s *ConcurrentRingBufferSuite) BenchmarkRingBuffer1to1(c *C) {
@@ -0,0 +1,251 @@
+package blockingQueues
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Item of a DelayQueue along with the time it becomes available
+type delayed[T any] struct {
+	value    T
+	deadline time.Time
+}
+
+/**
+ * DelayQueue is an unbounded multi-producer, multi-consumer queue
+ * where items can only be taken once their delay has expired.
+ * Items are taken in deadline order
+ */
+
+type DelayQueue[T any] struct {
+	// The number of items in the Queue
+	count uint64
+
+	// Main lock guarding all access
+	lock *sync.Mutex
+
+	// Condition for waiting reads, also signaled when the head changes
+	available *sync.Cond
+
+	// The underling store ordered by deadline
+	store *HeapStore[delayed[T]]
+
+	// Whether the queue has been closed
+	closed bool
+
+	// Closed when the queue gets closed
+	done chan struct{}
+}
+
+// Creates an empty DelayQueue
+func NewDelayQueue() *DelayQueue[interface{}] {
+	return NewDelayQueueOf[interface{}]()
+}
+
+// Creates an empty DelayQueue of T
+func NewDelayQueueOf[T any]() *DelayQueue[T] {
+	lock := new(sync.Mutex)
+
+	return &DelayQueue[T]{
+		lock:      lock,
+		available: sync.NewCond(lock),
+		count:     uint64(0),
+		store: NewHeapStore[delayed[T]](Unbounded, func(a, b delayed[T]) bool {
+			return a.deadline.Before(b.deadline)
+		}),
+		done: make(chan struct{}),
+	}
+}
+
+// Size returns this current elements size, due or not, is concurrent safe
+func (q *DelayQueue[T]) Size() uint64 {
+	q.lock.Lock()
+	res := q.count
+	q.lock.Unlock()
+
+	return res
+}
+
+// Capacity always returns Unbounded
+func (q *DelayQueue[T]) Capacity() uint64 {
+	return Unbounded
+}
+
+func (q *DelayQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Clears all the queues elements
+func (q *DelayQueue[T]) Clear() {
+	q.lock.Lock()
+
+	for ; q.count > 0; q.count -= 1 {
+		q.store.Remove(0)
+	}
+	q.lock.Unlock()
+}
+
+// Puts an element that becomes available after delay.
+// Never blocks the current goroutine
+func (q *DelayQueue[T]) Put(item T, delay time.Duration) (bool, error) {
+	return q.PutAt(item, time.Now().Add(delay))
+}
+
+// Puts an element that becomes available at deadline.
+// Never blocks the current goroutine
+func (q *DelayQueue[T]) PutAt(item T, deadline time.Time) (bool, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.lock.Lock()
+
+	if q.closed {
+		q.lock.Unlock()
+		return false, ErrorClosed
+	}
+
+	q.store.Set(delayed[T]{value: item, deadline: deadline}, 0)
+	q.count += 1
+
+	if q.store.Get(0).deadline.Equal(deadline) {
+		// The head changed so waiters need to reconsider how long to wait
+		q.available.Broadcast()
+	}
+	q.lock.Unlock()
+
+	return true, nil
+}
+
+// Pops the element with the earliest expired deadline.
+// Returns ErrorEmpty if no element is due yet.
+// Does not block the current goroutine
+func (q *DelayQueue[T]) Pop() (res T, err error) {
+	q.lock.Lock()
+
+	if q.count > 0 && !time.Now().Before(q.store.Get(0).deadline) {
+		res = q.pop()
+	} else if q.count == 0 && q.closed {
+		err = ErrorClosed
+	} else {
+		err = ErrorEmpty
+	}
+	q.lock.Unlock()
+
+	return
+}
+
+// Pops the head element. Call only when holding lock.
+func (q *DelayQueue[T]) pop() T {
+	q.count -= 1
+	return q.store.Remove(0).value
+}
+
+// Just attempts to return the element with the earliest deadline, due or not
+func (q *DelayQueue[T]) Peek() T {
+	q.lock.Lock()
+
+	var res T
+
+	if q.count > 0 {
+		res = q.store.Get(0).value
+	}
+	q.lock.Unlock()
+
+	return res
+}
+
+// Takes the element with the earliest deadline.
+// It blocks the current goroutine until that deadline has passed
+func (q *DelayQueue[T]) Get() (T, error) {
+	return q.GetContext(context.Background())
+}
+
+// Takes the element with the earliest deadline.
+// It blocks the current goroutine until that deadline has passed
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *DelayQueue[T]) GetContext(ctx context.Context) (T, error) {
+	var wakeUp = func() {
+		q.lock.Lock()
+		q.available.Broadcast()
+		q.lock.Unlock()
+	}
+
+	q.lock.Lock()
+
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, wakeUp)
+
+	for {
+		if q.count == 0 && q.closed {
+			q.lock.Unlock()
+			stop()
+
+			var zero T
+			return zero, ErrorClosed
+		}
+
+		var delay = time.Duration(0)
+		if q.count > 0 {
+			delay = time.Until(q.store.Get(0).deadline)
+			if delay <= 0 {
+				break
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			q.lock.Unlock()
+			stop()
+
+			var zero T
+			return zero, err
+		}
+
+		if q.count == 0 {
+			// We wait here until the queue has an item
+			q.available.Wait()
+		} else {
+			// We wait here until the head is due or the head changes
+			timer := time.AfterFunc(delay, wakeUp)
+			q.available.Wait()
+			timer.Stop()
+		}
+	}
+
+	// Critical section after wait released and head is due
+	var item = q.pop()
+	q.lock.Unlock()
+	stop()
+
+	return item, nil
+}
+
+// Closes the queue. Further Put calls are rejected with ErrorClosed while
+// the remaining elements can still be taken once due. Once the queue is
+// drained, Get returns ErrorClosed.
+// Closing an already closed queue has no effect
+func (q *DelayQueue[T]) Close() {
+	q.lock.Lock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+		q.available.Broadcast()
+	}
+	q.lock.Unlock()
+}
+
+// Reports whether the queue has been closed
+func (q *DelayQueue[T]) IsClosed() bool {
+	q.lock.Lock()
+	res := q.closed
+	q.lock.Unlock()
+
+	return res
+}
+
+// Returns a channel that is closed when the queue gets closed
+func (q *DelayQueue[T]) Done() <-chan struct{} {
+	return q.done
+}
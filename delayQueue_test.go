@@ -0,0 +1,141 @@
+package blockingQueues
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type DelayQueueSuite struct {
+	queue *DelayQueue[interface{}]
+}
+
+var _ = Suite(&DelayQueueSuite{})
+
+func (s *DelayQueueSuite) SetUpTest(c *C) {
+	s.queue = NewDelayQueue()
+}
+
+func (s *DelayQueueSuite) TestPopNotDue(c *C) {
+	s.queue.Put(1, time.Hour)
+
+	c.Assert(s.queue.Size(), Equals, uint64(1))
+	c.Assert(s.queue.Peek(), Equals, 1)
+
+	res, err := s.queue.Pop()
+	c.Assert(res, IsNil)
+	c.Assert(err, ErrorMatches, "ERROR_EMPTY: attempt to Get while Queue is Empty")
+}
+
+func (s *DelayQueueSuite) TestPopDue(c *C) {
+	s.queue.Put(1, time.Hour)
+	s.queue.PutAt(2, time.Now().Add(-time.Second))
+	s.queue.Put(3, 0)
+
+	item, err := s.queue.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 2)
+
+	item, err = s.queue.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 3)
+
+	_, err = s.queue.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+	c.Assert(s.queue.Size(), Equals, uint64(1))
+}
+
+func (s *DelayQueueSuite) TestGetWaitsForDeadline(c *C) {
+	start := time.Now()
+	s.queue.Put(1, 30*time.Millisecond)
+
+	item, err := s.queue.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+	c.Assert(time.Since(start) >= 30*time.Millisecond, Equals, true)
+}
+
+func (s *DelayQueueSuite) TestGetWakesForEarlierItem(c *C) {
+	s.queue.Put(1, time.Hour)
+	done := make(chan interface{})
+
+	go func() {
+		item, _ := s.queue.Get()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.queue.Put(2, 10*time.Millisecond)
+
+	select {
+	case item := <-done:
+		c.Assert(item, Equals, 2)
+	case <-time.After(time.Second):
+		c.Error("Get did not wake up for the earlier item")
+	}
+}
+
+func (s *DelayQueueSuite) TestGetWaitsForItem(c *C) {
+	done := make(chan interface{})
+
+	go func() {
+		item, _ := s.queue.Get()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.queue.Put(1, 0)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *DelayQueueSuite) TestGetContextDeadline(c *C) {
+	s.queue.Put(1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	item, err := s.queue.GetContext(ctx)
+	c.Assert(item, IsNil)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(s.queue.Size(), Equals, uint64(1))
+}
+
+func (s *DelayQueueSuite) TestClose(c *C) {
+	s.queue.Put(1, 10*time.Millisecond)
+	s.queue.Close()
+
+	res, err := s.queue.Put(2, 0)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorClosed)
+	c.Assert(s.queue.IsClosed(), Equals, true)
+
+	item, err := s.queue.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+
+	_, err = s.queue.Get()
+	c.Assert(err, Equals, ErrorClosed)
+}
+
+func (s *DelayQueueSuite) TestClear(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Put(i, time.Duration(i)*time.Millisecond)
+	}
+
+	s.queue.Clear()
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+	c.Assert(s.queue.Peek(), IsNil)
+}
+
+func (s *DelayQueueSuite) TestTyped(c *C) {
+	q := NewDelayQueueOf[string]()
+	now := time.Now()
+
+	q.PutAt("b", now.Add(-time.Millisecond))
+	q.PutAt("a", now.Add(-time.Second))
+
+	item, _ := q.Get()
+	c.Assert(item, Equals, "a")
+	item, _ = q.Get()
+	c.Assert(item, Equals, "b")
+}
@@ -9,3 +9,8 @@ import "errors"
 var ErrorCapacity = errors.New("ERROR_CAPACITY: attempt to Create Queue with invalid Capacity")
 var ErrorFull = errors.New("ERROR_FULL: attempt to Put while Queue is Full")
 var ErrorEmpty = errors.New("ERROR_EMPTY: attempt to Get while Queue is Empty")
+var ErrorClosed = errors.New("ERROR_CLOSED: attempt to use a Closed Queue")
+var ErrorCorrupt = errors.New("ERROR_CORRUPT: attempt to Recover a corrupt Queue file")
+var ErrorLayout = errors.New("ERROR_LAYOUT: attempt to Open a Queue file with a different Slot size")
+var ErrorItemSize = errors.New("ERROR_ITEM_SIZE: attempt to Put an Item larger than a Slot")
+var ErrorUnsupported = errors.New("ERROR_UNSUPPORTED: attempt to Map a Queue file on an unsupported platform")
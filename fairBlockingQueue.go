@@ -0,0 +1,106 @@
+package blockingQueues
+
+import (
+	"container/list"
+	"context"
+	"time"
+)
+
+// Matches blocked consumers with items and blocked producers with free
+// slots, in arrival order. Call only when holding lock of a fair queue.
+func (q *BlockingQueue[T]) handOff() {
+	for {
+		if q.count > 0 && q.getWaiters.Len() > 0 {
+			var consumer = q.getWaiters.Remove(q.getWaiters.Front()).(*syncWaiter[T])
+			consumer.elem = nil
+			consumer.item = q.dequeue()
+			close(consumer.matched)
+		} else if q.remaining() > 0 && q.putWaiters.Len() > 0 {
+			var producer = q.putWaiters.Remove(q.putWaiters.Front()).(*syncWaiter[T])
+			producer.elem = nil
+			q.enqueue(producer.item, producer.data)
+			close(producer.matched)
+		} else {
+			return
+		}
+	}
+}
+
+// Releases every blocked producer and consumer with ErrorClosed.
+// Call only when holding lock of a fair queue.
+func (q *BlockingQueue[T]) releaseWaiters() {
+	for _, waiters := range []*list.List{q.putWaiters, q.getWaiters} {
+		for waiters.Len() > 0 {
+			var waiter = waiters.Remove(waiters.Front()).(*syncWaiter[T])
+			waiter.elem = nil
+			waiter.err = ErrorClosed
+			close(waiter.matched)
+		}
+	}
+}
+
+// Takes an element from the head of a fair queue, waiting behind
+// the consumers that arrived earlier
+func (q *BlockingQueue[T]) fairGet(ctx context.Context) (T, error) {
+	q.lock.Lock()
+
+	// Consumers only ever wait on an empty queue, so nobody is ahead of us
+	if q.count > 0 {
+		var item = q.dequeue()
+		q.handOff()
+		q.lock.Unlock()
+
+		return item, nil
+	}
+
+	var zero T
+
+	if q.closed {
+		q.lock.Unlock()
+		return zero, ErrorClosed
+	}
+
+	// Read while holding lock, awaitMatch releases it
+	var metrics = q.metrics
+	var consumer = &syncWaiter[T]{matched: make(chan struct{})}
+	var start = startWait(metrics, time.Time{})
+	var err = awaitMatch(ctx, q.lock, q.getWaiters, consumer)
+	stopWait(metrics, start, false)
+	if err != nil {
+		return zero, err
+	}
+
+	return consumer.item, nil
+}
+
+// Puts an element to the tail of a fair queue, waiting behind
+// the producers that arrived earlier
+func (q *BlockingQueue[T]) fairPut(ctx context.Context, item T, data []byte) (bool, error) {
+	q.lock.Lock()
+
+	if q.closed {
+		q.lock.Unlock()
+		return false, ErrorClosed
+	}
+
+	// Producers only ever wait on a full queue, so nobody is ahead of us
+	if q.remaining() > 0 {
+		q.enqueue(item, data)
+		q.handOff()
+		q.lock.Unlock()
+
+		return true, nil
+	}
+
+	// Read while holding lock, awaitMatch releases it
+	var metrics = q.metrics
+	var producer = &syncWaiter[T]{item: item, data: data, matched: make(chan struct{})}
+	var start = startWait(metrics, time.Time{})
+	var err = awaitMatch(ctx, q.lock, q.putWaiters, producer)
+	stopWait(metrics, start, true)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,257 @@
+package blockingQueues
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type FairBlockingQueueSuite struct {
+	queue *BlockingQueue[interface{}]
+}
+
+var _ = Suite(&FairBlockingQueueSuite{})
+
+func (s *FairBlockingQueueSuite) SetUpTest(c *C) {
+	s.queue, _ = NewArrayBlockingQueueFair(1)
+}
+
+// Waits until n goroutines are blocked in waiters
+func waitForFairWaiters(q *BlockingQueue[interface{}], producers bool, n int) {
+	for {
+		q.lock.Lock()
+		var waiting = q.getWaiters.Len()
+		if producers {
+			waiting = q.putWaiters.Len()
+		}
+		q.lock.Unlock()
+
+		if waiting == n {
+			return
+		}
+		time.Sleep(10 * time.Microsecond)
+	}
+}
+
+func (s *FairBlockingQueueSuite) TestInvalidCapacity(c *C) {
+	_, err := NewArrayBlockingQueueFair(0)
+	c.Assert(err, ErrorMatches, "ERROR_CAPACITY: attempt to Create Queue with invalid Capacity")
+}
+
+func (s *FairBlockingQueueSuite) TestProducersInArrivalOrder(c *C) {
+	n := 2000
+	s.queue.Push(-1)
+
+	for i := 0; i < n; i++ {
+		go s.queue.Put(i)
+		waitForFairWaiters(s.queue, true, i+1)
+	}
+
+	// Newcomers must not jump ahead of the blocked producers
+	c.Assert(s.queue.Offer(n), Equals, false)
+
+	for i := -1; i < n; i++ {
+		item, err := s.queue.Get()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, i)
+	}
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+}
+
+func (s *FairBlockingQueueSuite) TestConsumersInArrivalOrder(c *C) {
+	n := 2000
+	received := make([]interface{}, n)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			received[i], _ = s.queue.Get()
+			wg.Done()
+		}(i)
+		waitForFairWaiters(s.queue, false, i+1)
+	}
+
+	for i := 0; i < n; i++ {
+		s.queue.Put(i)
+
+		// Newcomers must not steal items from the blocked consumers
+		_, err := s.queue.Pop()
+		c.Assert(err, Equals, ErrorEmpty)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		c.Assert(received[i], Equals, i)
+	}
+}
+
+// Producers blocked at known times are served before the thousands of
+// producers arriving later, whether those block in Put or keep retrying Offer
+func (s *FairBlockingQueueSuite) TestNoStarvation(c *C) {
+	early, late, retrying := 10, 1000, 100
+	s.queue.Push(-1)
+
+	for i := 0; i < early; i++ {
+		go s.queue.Put(i)
+		waitForFairWaiters(s.queue, true, i+1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < late; i++ {
+		wg.Add(1)
+		go func(i int) {
+			s.queue.PutContext(ctx, early+i)
+			wg.Done()
+		}(i)
+	}
+	waitForFairWaiters(s.queue, true, early+late)
+
+	for i := 0; i < retrying; i++ {
+		wg.Add(1)
+		go func() {
+			for ctx.Err() == nil {
+				s.queue.Offer(-2)
+				runtime.Gosched()
+			}
+			wg.Done()
+		}()
+	}
+
+	for i := -1; i < early; i++ {
+		item, err := s.queue.Get()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, i)
+
+		// The freed slot already went to the next blocked producer
+		c.Assert(s.queue.Offer(-3), Equals, false)
+	}
+
+	// Then the late producers, still ahead of the retrying ones
+	for i := 0; i < late; i++ {
+		item, err := s.queue.Get()
+		c.Assert(err, IsNil)
+		c.Assert(item.(int) >= early, Equals, true)
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+func (s *FairBlockingQueueSuite) TestCancelledWaiterLeavesLine(c *C) {
+	s.queue.Push(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := s.queue.PutContext(ctx, 1)
+		done <- err
+	}()
+	waitForFairWaiters(s.queue, true, 1)
+
+	go s.queue.Put(2)
+	waitForFairWaiters(s.queue, true, 2)
+
+	cancel()
+	c.Assert(<-done, Equals, context.Canceled)
+
+	for _, expected := range []int{0, 2} {
+		item, _ := s.queue.Get()
+		c.Assert(item, Equals, expected)
+	}
+}
+
+func (s *FairBlockingQueueSuite) TestCloseReleasesWaiters(c *C) {
+	s.queue.Push(0)
+	full := s.queue
+
+	empty, _ := NewArrayBlockingQueueFair(1)
+	errs := make(chan error, 2)
+
+	go func() {
+		_, err := full.Put(1)
+		errs <- err
+	}()
+	go func() {
+		_, err := empty.Get()
+		errs <- err
+	}()
+	waitForFairWaiters(full, true, 1)
+	waitForFairWaiters(empty, false, 1)
+
+	full.Close()
+	empty.Close()
+
+	c.Assert(<-errs, Equals, ErrorClosed)
+	c.Assert(<-errs, Equals, ErrorClosed)
+
+	item, err := full.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 0)
+}
+
+func (s *FairBlockingQueueSuite) TestPutAll(c *C) {
+	q, _ := NewArrayBlockingQueueFair(4)
+	done := make(chan int)
+
+	go func() {
+		n, _ := q.PutAll([]interface{}{0, 1, 2, 3, 4, 5, 6, 7})
+		done <- n
+	}()
+
+	for i := 0; i < 8; i++ {
+		item, _ := q.Get()
+		c.Assert(item, Equals, i)
+	}
+	c.Assert(<-done, Equals, 8)
+}
+
+func (s *FairBlockingQueueSuite) TestDrainToAdmitsProducers(c *C) {
+	q, _ := NewArrayBlockingQueueFair(2)
+	q.Push(0)
+	q.Push(1)
+
+	go q.Put(2)
+	waitForFairWaiters(q, true, 1)
+	go q.Put(3)
+	waitForFairWaiters(q, true, 2)
+
+	dst := make([]interface{}, 2)
+	c.Assert(q.DrainTo(dst, 2), Equals, 2)
+	c.Assert(q.Size(), Equals, uint64(2))
+
+	c.Assert(q.DrainTo(dst, 2), Equals, 2)
+	c.Assert(dst, DeepEquals, []interface{}{2, 3})
+}
+
+func (s *FairBlockingQueueSuite) TestSetCapacityAdmitsProducers(c *C) {
+	s.queue.Push(0)
+
+	for i := 1; i <= 3; i++ {
+		go s.queue.Put(i)
+		waitForFairWaiters(s.queue, true, i)
+	}
+
+	c.Assert(s.queue.SetCapacity(3), IsNil)
+	waitForFairWaiters(s.queue, true, 1)
+	c.Assert(s.queue.Size(), Equals, uint64(3))
+
+	for i := 0; i <= 3; i++ {
+		item, _ := s.queue.Get()
+		c.Assert(item, Equals, i)
+	}
+}
+
+func (s *FairBlockingQueueSuite) BenchmarkPut1to1(c *C) {
+	q, _ := NewArrayBlockingQueueFair(1024)
+	benchmarkPut(c, 1, 1, q)
+}
+
+func (s *FairBlockingQueueSuite) BenchmarkPut4to4(c *C) {
+	q, _ := NewArrayBlockingQueueFair(1024)
+	benchmarkPut(c, 4, 4, q)
+}
@@ -0,0 +1,430 @@
+package blockingQueues
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Size after which the write-ahead log moves on to a new segment
+const defaultSegmentSize = 4 << 20
+
+// Kinds of write-ahead log records
+const (
+	// An item was added, the payload holds the encoded item
+	recordPut byte = 'P'
+
+	// An item was taken or removed, there is no payload
+	recordDel byte = 'D'
+)
+
+// Record header: length of kind, seq and payload then their crc32
+const recordHeaderSize = 8
+
+// Kind and seq
+const recordMinSize = 9
+
+// Records claiming to be larger are considered corrupt
+const recordMaxSize = 1 << 30
+
+// Item along with its sequence number and the segment holding its PUT record
+type fileEntry[T any] struct {
+	value   T
+	seq     uint64
+	segment *walSegment
+}
+
+// Write-ahead log file along with how many of its PUT records are not consumed
+type walSegment struct {
+	id   uint64
+	path string
+	live int
+}
+
+/**
+ * FileStore is a QueueStore keeping its items in memory and in a
+ * write-ahead log under a directory, so they survive a restart.
+ * Every added item is appended as a PUT record and every taken or
+ * removed one as a DEL record. The log is split into segments, which
+ * are deleted once all of their items are consumed.
+ * Records are written straight to the files, so they survive a crash
+ * of the process. A segment is synced once finished and when the store
+ * is closed, so a crash of the machine only loses the records of the
+ * segment being written
+ */
+type FileStore[T any] struct {
+	dir      string
+	codec    Codec[T]
+	items    *list.List
+	capacity uint64
+
+	// Sequence number of the next PUT record
+	seq uint64
+
+	// Segments in order, the last one is being written
+	segments    []*walSegment
+	file        *os.File
+	written     int64
+	segmentSize int64
+
+	// First write error, after which the log is no longer written
+	err    error
+	closed bool
+}
+
+// Opens the FileStore in dir, creating it if needed, and recovers the items
+// of a previous run. A record cut short at the end of the log, as left by a
+// crash, is discarded.
+// returns an error if the log cannot be read or is corrupt
+func NewFileStore[T any](dir string, capacity uint64, codec Codec[T]) (*FileStore[T], error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var s = &FileStore[T]{
+		dir:         dir,
+		codec:       codec,
+		items:       list.New(),
+		capacity:    capacity,
+		segmentSize: defaultSegmentSize,
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	if err := s.roll(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Replays the segments found in the directory
+func (s *FileStore[T]) recover() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.wal"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	// Entries by seq, to apply DEL records
+	var entries = make(map[uint64]*list.Element)
+
+	for i, path := range paths {
+		id, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), ".wal"), 10, 64)
+		if err != nil {
+			return &os.PathError{Op: "recover", Path: path, Err: ErrorCorrupt}
+		}
+
+		var segment = &walSegment{id: id, path: path}
+		s.segments = append(s.segments, segment)
+
+		if err := s.replay(segment, entries, i == len(paths)-1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Applies the records of segment. A truncated or corrupt record is only
+// tolerated at the end of the last segment, where it is cut off
+func (s *FileStore[T]) replay(segment *walSegment, entries map[uint64]*list.Element, last bool) error {
+	f, err := os.Open(segment.path)
+	if err != nil {
+		return err
+	}
+
+	var r = bufio.NewReader(f)
+	var offset int64
+	var header [recordHeaderSize]byte
+
+	for {
+		if _, err = io.ReadFull(r, header[:]); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			err = ErrorCorrupt
+			break
+		}
+
+		var size = binary.LittleEndian.Uint32(header[0:4])
+		if size < recordMinSize || size > recordMaxSize {
+			err = ErrorCorrupt
+			break
+		}
+
+		var body = make([]byte, size)
+		if _, err = io.ReadFull(r, body); err != nil || crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[4:8]) {
+			err = ErrorCorrupt
+			break
+		}
+
+		var seq = binary.LittleEndian.Uint64(body[1:9])
+		switch body[0] {
+		case recordPut:
+			value, err := s.codec.Decode(body[9:])
+			if err != nil {
+				f.Close()
+				return err
+			}
+			entries[seq] = s.items.PushBack(&fileEntry[T]{value: value, seq: seq, segment: segment})
+			segment.live += 1
+			s.seq = seq + 1
+		case recordDel:
+			if e, ok := entries[seq]; ok {
+				s.items.Remove(e).(*fileEntry[T]).segment.live -= 1
+				delete(entries, seq)
+			}
+		default:
+			f.Close()
+			return &os.PathError{Op: "recover", Path: segment.path, Err: ErrorCorrupt}
+		}
+
+		offset += int64(recordHeaderSize + size)
+	}
+	f.Close()
+
+	if err == ErrorCorrupt && last {
+		// The process stopped while appending this record
+		return os.Truncate(segment.path, offset)
+	} else if err != nil {
+		return &os.PathError{Op: "recover", Path: segment.path, Err: err}
+	}
+
+	return nil
+}
+
+// Moves on to a new segment and deletes the consumed ones.
+// The finished segment is synced first
+func (s *FileStore[T]) roll() error {
+	if s.file != nil {
+		if err := s.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	var id = uint64(0)
+	if len(s.segments) > 0 {
+		id = s.segments[len(s.segments)-1].id + 1
+	}
+
+	var segment = &walSegment{
+		id:   id,
+		path: filepath.Join(s.dir, fmt.Sprintf("%020d.wal", id)),
+	}
+
+	f, err := os.OpenFile(segment.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = f
+	s.written = 0
+	s.segments = append(s.segments, segment)
+
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+
+	return s.compact()
+}
+
+// Deletes the oldest segments once all of their items are consumed.
+// Records of a segment only ever refer to items of the same or older
+// segments, so going from the oldest keeps the log consistent
+func (s *FileStore[T]) compact() error {
+	for len(s.segments) > 1 && s.segments[0].live == 0 {
+		if err := os.Remove(s.segments[0].path); err != nil {
+			return err
+		}
+		s.segments = s.segments[1:]
+	}
+
+	return nil
+}
+
+// Appends a record to the current segment, rolling over once it is large enough.
+// Does nothing after an error or once closed
+func (s *FileStore[T]) write(kind byte, seq uint64, payload []byte) {
+	if s.err != nil || s.closed {
+		return
+	}
+
+	var record = make([]byte, recordHeaderSize+recordMinSize+len(payload))
+	var body = record[recordHeaderSize:]
+	body[0] = kind
+	binary.LittleEndian.PutUint64(body[1:9], seq)
+	copy(body[9:], payload)
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(body)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(body))
+
+	n, err := s.file.Write(record)
+	s.written += int64(n)
+	if err == nil && s.written >= s.segmentSize {
+		err = s.roll()
+	}
+	s.err = err
+}
+
+// Records that the item of e was taken or removed
+func (s *FileStore[T]) del(e *list.Element) T {
+	var entry = s.items.Remove(e).(*fileEntry[T])
+	s.write(recordDel, entry.seq, nil)
+
+	entry.segment.live -= 1
+	if s.err == nil && !s.closed {
+		s.err = s.compact()
+	}
+
+	return entry.value
+}
+
+// Refuses the items the codec cannot encode, so they are rejected
+// instead of being kept in memory only. Returns the encoded item
+func (s *FileStore[T]) checkItem(value T) ([]byte, error) {
+	return s.codec.Encode(value)
+}
+
+func (s *FileStore[T]) Set(value T, pos uint64) {
+	payload, err := s.codec.Encode(value)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	s.setEncoded(value, payload, pos)
+}
+
+// Adds value, encoded as payload by checkItem
+func (s *FileStore[T]) setEncoded(value T, payload []byte, pos uint64) {
+	var entry = &fileEntry[T]{value: value, seq: s.seq, segment: s.segments[len(s.segments)-1]}
+	s.seq += 1
+
+	// Count the item before writing so rolling over keeps its segment
+	entry.segment.live += 1
+	s.items.PushBack(entry)
+
+	s.write(recordPut, entry.seq, payload)
+}
+
+func (s *FileStore[T]) Get(pos uint64) T {
+	return s.items.Front().Value.(*fileEntry[T]).value
+}
+
+func (s *FileStore[T]) Remove(pos uint64) T {
+	return s.del(s.items.Front())
+}
+
+func (s *FileStore[T]) Items(pos uint64, count uint64) []T {
+	var res = make([]T, 0, count)
+	for e := s.items.Front(); e != nil && uint64(len(res)) < count; e = e.Next() {
+		res = append(res, e.Value.(*fileEntry[T]).value)
+	}
+
+	return res
+}
+
+func (s *FileStore[T]) RemoveIf(pos uint64, count uint64, pred func(T) bool) uint64 {
+	var removed = uint64(0)
+	for e := s.items.Front(); e != nil; {
+		var next = e.Next()
+		if pred(e.Value.(*fileEntry[T]).value) {
+			s.del(e)
+			removed += 1
+		}
+		e = next
+	}
+
+	return removed
+}
+
+func (s *FileStore[T]) Resize(pos uint64, count uint64, size uint64) error {
+	s.capacity = size
+	return nil
+}
+
+func (s FileStore[T]) Size() uint64 {
+	return s.capacity
+}
+
+// Returns the number of items recovered or added and not yet taken
+func (s *FileStore[T]) Len() uint64 {
+	return uint64(s.items.Len())
+}
+
+// Returns the first error met while writing the log. The items are still
+// kept in memory but the later changes are not written
+func (s *FileStore[T]) Err() error {
+	return s.err
+}
+
+// Syncs and closes the log. The items taken afterwards are not recorded,
+// so they are recovered again on restart. A closed BlockingQueue
+// closes its store only once drained
+func (s *FileStore[T]) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var err = s.file.Sync()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = syncDir(s.dir)
+	}
+	if s.err == nil {
+		s.err = err
+	}
+
+	return err
+}
+
+// Creates an BlockingQueue backed by a FileStore in dir with the given
+// (fixed) capacity, recovering the items left by a previous run.
+// The store is closed once the closed queue is drained.
+// returns an error if the capacity is less than 1 or the store cannot be opened
+func NewPersistentBlockingQueue(dir string, capacity uint64, codec Codec[interface{}]) (*BlockingQueue[interface{}], error) {
+	return NewPersistentBlockingQueueOf[interface{}](dir, capacity, codec)
+}
+
+// Creates an BlockingQueue of T backed by a FileStore in dir with the given
+// (fixed) capacity, recovering the items left by a previous run.
+// The store is closed once the closed queue is drained.
+// returns an error if the capacity is less than 1 or the store cannot be opened
+func NewPersistentBlockingQueueOf[T any](dir string, capacity uint64, codec Codec[T]) (*BlockingQueue[T], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
+
+	store, err := NewFileStore[T](dir, capacity, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the recovered items even if the capacity was lowered since
+	var count = store.Len()
+	store.Resize(0, count, max(capacity, count))
+
+	var q = newBlockingQueue[T](store)
+	q.count = count
+	q.writeIndex = q.advance(0, count)
+	q.capacity = capacity
+
+	return q, nil
+}
+
+var _ QueueStore[interface{}] = (*FileStore[interface{}])(nil)
+var _ io.Closer = (*FileStore[interface{}])(nil)
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package blockingQueues
+
+// Directories cannot be synced everywhere, their entries are left to the
+// operating system
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,265 @@
+package blockingQueues
+
+import (
+	"bytes"
+	"errors"
+	. "gopkg.in/check.v1"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Encodes ints as decimal text
+type intCodec struct{}
+
+func (intCodec) Encode(item int) ([]byte, error) {
+	return []byte(strconv.Itoa(item)), nil
+}
+
+func (intCodec) Decode(data []byte) (int, error) {
+	return strconv.Atoi(string(data))
+}
+
+// Refuses to encode negative ints
+type failingCodec struct {
+	intCodec
+}
+
+func (failingCodec) Encode(item int) ([]byte, error) {
+	if item < 0 {
+		return nil, errors.New("negative")
+	}
+	return intCodec{}.Encode(item)
+}
+
+// Counts the items it encodes
+type countingCodec struct {
+	intCodec
+	encoded *int
+}
+
+func (c countingCodec) Encode(item int) ([]byte, error) {
+	*c.encoded += 1
+	return intCodec{}.Encode(item)
+}
+
+type FileStoreSuite struct {
+	dir string
+}
+
+var _ = Suite(&FileStoreSuite{})
+
+func (s *FileStoreSuite) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+}
+
+func (s *FileStoreSuite) open(c *C, capacity uint64) *BlockingQueue[int] {
+	q, err := NewPersistentBlockingQueueOf[int](s.dir, capacity, intCodec{})
+	c.Assert(err, IsNil)
+
+	return q
+}
+
+func (s *FileStoreSuite) segments(c *C) []string {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.wal"))
+	c.Assert(err, IsNil)
+
+	return paths
+}
+
+func (s *FileStoreSuite) TestInvalidCapacity(c *C) {
+	_, err := NewPersistentBlockingQueue(s.dir, 0, nil)
+	c.Assert(err, Equals, ErrorCapacity)
+}
+
+func (s *FileStoreSuite) TestRecover(c *C) {
+	q := s.open(c, 8)
+	for i := 1; i <= 5; i++ {
+		q.Put(i)
+	}
+	q.Get()
+	q.Get()
+	q.Close()
+	c.Assert(q.Err(), IsNil)
+
+	q = s.open(c, 8)
+	c.Assert(q.Size(), Equals, uint64(3))
+	c.Assert(q.Capacity(), Equals, uint64(5))
+	q.Put(6)
+
+	for i := 3; i <= 6; i++ {
+		item, _ := q.Get()
+		c.Assert(item, Equals, i)
+	}
+	q.Close()
+
+	q = s.open(c, 8)
+	c.Assert(q.IsEmpty(), Equals, true)
+}
+
+func (s *FileStoreSuite) TestRemoveIfRecorded(c *C) {
+	q := s.open(c, 8)
+	for i := 0; i < 8; i++ {
+		q.Put(i)
+	}
+	q.RemoveIf(func(item int) bool { return item%2 == 0 })
+	q.Close()
+
+	q = s.open(c, 8)
+	c.Assert(q.Snapshot(), DeepEquals, []int{1, 3, 5, 7})
+}
+
+func (s *FileStoreSuite) TestTakenAfterCloseRecorded(c *C) {
+	q := s.open(c, 8)
+	q.PutAll([]int{1, 2})
+	q.Close()
+
+	item, _ := q.Get()
+	c.Assert(item, Equals, 1)
+	c.Assert(s.open(c, 8).Snapshot(), DeepEquals, []int{2})
+
+	// The log is closed once the queue is drained
+	item, _ = q.Get()
+	c.Assert(item, Equals, 2)
+	c.Assert(q.store.(*FileStore[int]).closed, Equals, true)
+	c.Assert(q.Err(), IsNil)
+	c.Assert(s.open(c, 8).Size(), Equals, uint64(0))
+}
+
+func (s *FileStoreSuite) TestLoweredCapacity(c *C) {
+	q := s.open(c, 8)
+	for i := 0; i < 5; i++ {
+		q.Put(i)
+	}
+	q.Close()
+
+	q = s.open(c, 2)
+	c.Assert(q.Size(), Equals, uint64(5))
+	c.Assert(q.Capacity(), Equals, uint64(0))
+	c.Assert(q.Offer(5), Equals, false)
+
+	for i := 0; i < 4; i++ {
+		item, _ := q.Get()
+		c.Assert(item, Equals, i)
+	}
+	c.Assert(q.Offer(5), Equals, true)
+	c.Assert(q.Snapshot(), DeepEquals, []int{4, 5})
+}
+
+func (s *FileStoreSuite) TestSegmentsDeleted(c *C) {
+	q := s.open(c, 1000)
+	q.store.(*FileStore[int]).segmentSize = 64
+
+	for i := 0; i < 100; i++ {
+		q.Put(i)
+	}
+	c.Assert(len(s.segments(c)) > 10, Equals, true)
+
+	// Segments are kept while older ones hold items
+	for i := 0; i < 90; i++ {
+		q.Get()
+	}
+	q.Close()
+
+	q = s.open(c, 1000)
+	q.store.(*FileStore[int]).segmentSize = 64
+	c.Assert(q.Size(), Equals, uint64(10))
+	c.Assert(q.Peek(), Equals, 90)
+
+	for i := 0; i < 10; i++ {
+		q.Get()
+	}
+	c.Assert(len(s.segments(c)) <= 2, Equals, true)
+	q.Close()
+
+	// Only the segment being written is kept
+	q = s.open(c, 1000)
+	c.Assert(q.IsEmpty(), Equals, true)
+	c.Assert(s.segments(c), HasLen, 1)
+}
+
+func (s *FileStoreSuite) TestTruncatedRecord(c *C) {
+	q := s.open(c, 8)
+	q.Put(1)
+	q.Put(2)
+	q.Close()
+
+	// Cut the last record short as a crash while writing would
+	paths := s.segments(c)
+	last := paths[len(paths)-1]
+	info, err := os.Stat(last)
+	c.Assert(err, IsNil)
+	c.Assert(os.Truncate(last, info.Size()-1), IsNil)
+
+	q = s.open(c, 8)
+	c.Assert(q.Snapshot(), DeepEquals, []int{1})
+
+	// The log is usable again
+	q.Put(3)
+	q.Close()
+
+	q = s.open(c, 8)
+	c.Assert(q.Snapshot(), DeepEquals, []int{1, 3})
+}
+
+func (s *FileStoreSuite) TestCorruptSegment(c *C) {
+	q := s.open(c, 8)
+	q.store.(*FileStore[int]).segmentSize = 16
+	q.Put(1)
+	q.Put(2)
+	q.Close()
+
+	paths := s.segments(c)
+	c.Assert(len(paths) > 1, Equals, true)
+
+	data, err := os.ReadFile(paths[0])
+	c.Assert(err, IsNil)
+	data[len(data)-1] ^= 0xff
+	c.Assert(os.WriteFile(paths[0], data, 0644), IsNil)
+
+	_, err = NewPersistentBlockingQueueOf[int](s.dir, 8, intCodec{})
+	c.Assert(errors.Is(err, ErrorCorrupt), Equals, true)
+}
+
+func (s *FileStoreSuite) TestEncodeError(c *C) {
+	q, err := NewPersistentBlockingQueueOf[int](s.dir, 8, failingCodec{})
+	c.Assert(err, IsNil)
+
+	q.Put(1)
+	_, err = q.Put(-1)
+	c.Assert(err, ErrorMatches, "negative")
+	c.Assert(q.Offer(-1), Equals, false)
+	c.Assert(q.OfferBatch([]int{2, -1}), Equals, false)
+	c.Assert(q.OfferAll([]int{2, -1, 3}), Equals, 1)
+	n, err := q.PutAll([]int{3, -1, 4})
+	c.Assert(n, Equals, 1)
+	c.Assert(err, ErrorMatches, "negative")
+
+	// Only the refused items are missing and the log is still written
+	c.Assert(q.Err(), IsNil)
+	q.Get()
+	c.Assert(q.Snapshot(), DeepEquals, []int{2, 3})
+	q.Close()
+
+	q = s.open(c, 8)
+	c.Assert(q.Snapshot(), DeepEquals, []int{2, 3})
+}
+
+func (s *FileStoreSuite) TestEncodedOnce(c *C) {
+	var encoded = 0
+	q, err := NewPersistentBlockingQueueOf[int](s.dir, 16, countingCodec{encoded: &encoded})
+	c.Assert(err, IsNil)
+
+	q.Put(1)
+	q.Offer(2)
+	q.OfferAll([]int{3, 4})
+	q.OfferBatch([]int{5, 6})
+	q.PutAll([]int{7, 8})
+	c.Assert(encoded, Equals, 8)
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, intCodec{}), IsNil)
+	c.Assert(q.RestoreFrom(&buf, intCodec{}), IsNil)
+	c.Assert(encoded, Equals, 16)
+	q.Close()
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin
+
+package blockingQueues
+
+import "os"
+
+// Writes back the entries of dir, so created and deleted files survive a crash
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	err = f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,88 @@
+package blockingQueues
+
+import (
+	"iter"
+	"sync/atomic"
+)
+
+// Returns a copy of the elements in the queue, from head to tail.
+// Does not remove any element
+func (q *BlockingQueue[T]) Snapshot() []T {
+	q.lock.Lock()
+	var res = q.store.Items(q.readIndex, q.count)
+	q.lock.Unlock()
+
+	return res
+}
+
+// Returns an iterator over a Snapshot of the queue.
+// Elements added or removed while ranging are not seen
+func (q *BlockingQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range q.Snapshot() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator removing and yielding the elements at the head of
+// the queue until it is empty or closed and drained.
+// Does not block the current goroutine
+func (q *BlockingQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			item, err := q.Pop()
+			if err != nil || !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Returns a copy of the written elements in the buffer, from head to tail.
+// Does not remove any element. Elements taken while copying are skipped
+// and elements put after the call are not seen
+func (q *ConcurrentRingBuffer[T]) Snapshot() []T {
+	var head = atomic.LoadUint64(&q.dequeuePos)
+	var tail = atomic.LoadUint64(&q.enqueuePos)
+	var res = make([]T, 0, tail-head)
+
+	for pos := head; pos < tail; pos++ {
+		item, ok := q.peekAt(pos)
+		if ok {
+			res = append(res, item)
+		} else if pos >= atomic.LoadUint64(&q.dequeuePos) {
+			// Reached the elements not written yet
+			break
+		}
+	}
+
+	return res
+}
+
+// Returns an iterator over a Snapshot of the buffer
+func (q *ConcurrentRingBuffer[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range q.Snapshot() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator taking and yielding the elements at the head of
+// the buffer until it is empty.
+// Does not block the current goroutine
+func (q *ConcurrentRingBuffer[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			item, err := q.Pop()
+			if err != nil || !yield(item) {
+				return
+			}
+		}
+	}
+}
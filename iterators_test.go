@@ -0,0 +1,110 @@
+package blockingQueues
+
+import (
+	. "gopkg.in/check.v1"
+	"slices"
+)
+
+type IteratorsSuite struct{}
+
+var _ = Suite(&IteratorsSuite{})
+
+func (s *IteratorsSuite) TestSnapshotArray(c *C) {
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	c.Assert(q.Snapshot(), DeepEquals, []int{})
+
+	// Move the head so the items wrap around the end of the array
+	for i := 0; i < 3; i++ {
+		q.Put(i)
+		q.Get()
+	}
+	for i := 0; i < 4; i++ {
+		q.Put(i)
+	}
+
+	c.Assert(q.Snapshot(), DeepEquals, []int{0, 1, 2, 3})
+	c.Assert(q.Size(), Equals, uint64(4))
+}
+
+func (s *IteratorsSuite) TestSnapshotLinked(c *C) {
+	q := NewUnboundedLinkedBlockingQueueOf[string]()
+	q.Put("a")
+	q.Put("b")
+	q.Get()
+	q.Put("c")
+
+	c.Assert(q.Snapshot(), DeepEquals, []string{"b", "c"})
+	c.Assert(q.Size(), Equals, uint64(2))
+}
+
+func (s *IteratorsSuite) TestSnapshotPriority(c *C) {
+	q, _ := NewPriorityBlockingQueueOf[int](8, func(a, b int) bool { return a < b })
+	for _, i := range []int{5, 1, 4, 2, 3} {
+		q.Put(i)
+	}
+
+	c.Assert(q.Snapshot(), DeepEquals, []int{1, 2, 3, 4, 5})
+
+	item, _ := q.Get()
+	c.Assert(item, Equals, 1)
+}
+
+func (s *IteratorsSuite) TestAll(c *C) {
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	q.Put(1)
+	q.Put(2)
+	q.Put(3)
+
+	var items []int
+	for item := range q.All() {
+		items = append(items, item)
+		if item == 2 {
+			break
+		}
+	}
+
+	c.Assert(items, DeepEquals, []int{1, 2})
+	c.Assert(slices.Collect(q.All()), DeepEquals, []int{1, 2, 3})
+	c.Assert(q.Size(), Equals, uint64(3))
+}
+
+func (s *IteratorsSuite) TestDrain(c *C) {
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	q.Put(1)
+	q.Put(2)
+	q.Put(3)
+
+	for item := range q.Drain() {
+		c.Assert(item, Equals, 1)
+		break
+	}
+	c.Assert(q.Size(), Equals, uint64(2))
+
+	c.Assert(slices.Collect(q.Drain()), DeepEquals, []int{2, 3})
+	c.Assert(q.IsEmpty(), Equals, true)
+}
+
+func (s *IteratorsSuite) TestDrainClosed(c *C) {
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	q.Put(1)
+	q.Close()
+
+	c.Assert(slices.Collect(q.Drain()), DeepEquals, []int{1})
+	c.Assert(slices.Collect(q.Drain()), IsNil)
+}
+
+func (s *IteratorsSuite) TestRingBuffer(c *C) {
+	q, _ := NewConcurrentRingBufferOf[int](4)
+	c.Assert(q.Snapshot(), DeepEquals, []int{})
+
+	for i := 0; i < 3; i++ {
+		q.Put(i)
+	}
+	q.Get()
+	q.Put(3)
+
+	c.Assert(q.Snapshot(), DeepEquals, []int{1, 2, 3})
+	c.Assert(slices.Collect(q.All()), DeepEquals, []int{1, 2, 3})
+	c.Assert(slices.Collect(q.Drain()), DeepEquals, []int{1, 2, 3})
+	c.Assert(q.Snapshot(), DeepEquals, []int{})
+}
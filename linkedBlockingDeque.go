@@ -0,0 +1,68 @@
+package blockingQueues
+
+import "container/list"
+
+type LinkedDequeStore[T any] struct {
+	store    *list.List
+	capacity uint64
+}
+
+func NewLinkedDequeStore[T any](capacity uint64) *LinkedDequeStore[T] {
+	return &LinkedDequeStore[T]{
+		store:    list.New(),
+		capacity: capacity,
+	}
+}
+
+func (s *LinkedDequeStore[T]) PushFront(value T) {
+	s.store.PushFront(value)
+}
+
+func (s *LinkedDequeStore[T]) PushBack(value T) {
+	s.store.PushBack(value)
+}
+
+func (s *LinkedDequeStore[T]) PopFront() T {
+	return s.store.Remove(s.store.Front()).(T)
+}
+
+func (s *LinkedDequeStore[T]) PopBack() T {
+	return s.store.Remove(s.store.Back()).(T)
+}
+
+func (s *LinkedDequeStore[T]) Front() T {
+	return s.store.Front().Value.(T)
+}
+
+func (s *LinkedDequeStore[T]) Back() T {
+	return s.store.Back().Value.(T)
+}
+
+func (s *LinkedDequeStore[T]) Items() []T {
+	var res = make([]T, 0, s.store.Len())
+	for e := s.store.Front(); e != nil; e = e.Next() {
+		res = append(res, e.Value.(T))
+	}
+
+	return res
+}
+
+func (s LinkedDequeStore[T]) Size() uint64 {
+	return s.capacity
+}
+
+// Creates an BlockingDeque backed by an LinkedList with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewLinkedBlockingDeque(capacity uint64) (*BlockingDeque[interface{}], error) {
+	return NewLinkedBlockingDequeOf[interface{}](capacity)
+}
+
+// Creates an BlockingDeque of T backed by an LinkedList with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewLinkedBlockingDequeOf[T any](capacity uint64) (*BlockingDeque[T], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
+
+	return newBlockingDeque[T](NewLinkedDequeStore[T](capacity)), nil
+}
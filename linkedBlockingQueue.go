@@ -1,53 +1,88 @@
 package blockingQueues
 
-import (
-	"container/list"
-	"sync"
-)
+import "container/list"
 
-type LinkedListStore struct {
+type LinkedListStore[T any] struct {
 	store    *list.List
 	capacity uint64
 }
 
-func NewLinkedListStore(capacity uint64) *LinkedListStore {
-	return &LinkedListStore{
+func NewLinkedListStore[T any](capacity uint64) *LinkedListStore[T] {
+	return &LinkedListStore[T]{
 		store:    list.New(),
 		capacity: capacity,
 	}
 }
 
-func (s *LinkedListStore) Set(value interface{}, pos uint64) {
+func (s *LinkedListStore[T]) Set(value T, pos uint64) {
 	s.store.PushBack(value)
 }
 
-func (s *LinkedListStore) Get(pos uint64) interface{} {
-	return s.store.Front()
+func (s *LinkedListStore[T]) Get(pos uint64) T {
+	return s.store.Front().Value.(T)
 }
 
-func (s *LinkedListStore) Remove(pos uint64) interface{} {
+func (s *LinkedListStore[T]) Remove(pos uint64) T {
 	var item = s.store.Remove(s.store.Front())
-	return item
+	return item.(T)
 }
 
-func (s LinkedListStore) Size() uint64 {
+func (s *LinkedListStore[T]) Items(pos uint64, count uint64) []T {
+	var res = make([]T, 0, count)
+	for e := s.store.Front(); e != nil && uint64(len(res)) < count; e = e.Next() {
+		res = append(res, e.Value.(T))
+	}
+
+	return res
+}
+
+func (s *LinkedListStore[T]) RemoveIf(pos uint64, count uint64, pred func(T) bool) uint64 {
+	var removed = uint64(0)
+	for e := s.store.Front(); e != nil; {
+		var next = e.Next()
+		if pred(e.Value.(T)) {
+			s.store.Remove(e)
+			removed += 1
+		}
+		e = next
+	}
+
+	return removed
+}
+
+func (s *LinkedListStore[T]) Resize(pos uint64, count uint64, size uint64) error {
+	s.capacity = size
+	return nil
+}
+
+func (s LinkedListStore[T]) Size() uint64 {
 	return s.capacity
 }
 
 // Creates an BlockingQueue backed by an LinkedList with the given (fixed) capacity
 // returns an error if the capacity is less than 1
-func NewLinkedBlockingQueue(capacity uint64) (*BlockingQueue, error) {
+func NewLinkedBlockingQueue(capacity uint64) (*BlockingQueue[interface{}], error) {
+	return NewLinkedBlockingQueueOf[interface{}](capacity)
+}
+
+// Creates an BlockingQueue of T backed by an LinkedList with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewLinkedBlockingQueueOf[T any](capacity uint64) (*BlockingQueue[T], error) {
 	if capacity < 1 {
 		return nil, ErrorCapacity
 	}
 
-	lock := new(sync.Mutex)
+	return newBlockingQueue[T](NewLinkedListStore[T](capacity)), nil
+}
+
+// Creates an BlockingQueue backed by an LinkedList without a bound.
+// Put never blocks and Capacity always returns Unbounded
+func NewUnboundedLinkedBlockingQueue() *BlockingQueue[interface{}] {
+	return NewUnboundedLinkedBlockingQueueOf[interface{}]()
+}
 
-	return &BlockingQueue{
-		lock:     lock,
-		notEmpty: sync.NewCond(lock),
-		notFull:  sync.NewCond(lock),
-		count:    uint64(0),
-		store:    NewLinkedListStore(capacity),
-	}, nil
+// Creates an BlockingQueue of T backed by an LinkedList without a bound.
+// Put never blocks and Capacity always returns Unbounded
+func NewUnboundedLinkedBlockingQueueOf[T any]() *BlockingQueue[T] {
+	return newBlockingQueue[T](NewLinkedListStore[T](Unbounded))
 }
@@ -2,14 +2,15 @@ package blockingQueues
 
 
 import (
-	"container/list"
+	"context"
 	. "gopkg.in/check.v1"
 	"math"
+	"time"
 )
 
 type LinkedBlockingQueueSuite struct {
-	queue  *BlockingQueue
-	queue2 *BlockingQueue
+	queue  *BlockingQueue[interface{}]
+	queue2 *BlockingQueue[interface{}]
 }
 
 var _ = Suite(&LinkedBlockingQueueSuite{})
@@ -92,11 +93,11 @@ func (s *LinkedBlockingQueueSuite) TestPeek(c *C) {
 		s.queue.Push(i)
 	}
 
-	c.Assert(s.queue.Peek().(*list.Element).Value, Equals, 0)
+	c.Assert(s.queue.Peek(), Equals, 0)
 
 	s.queue.Pop()
 
-	c.Assert(s.queue.Peek().(*list.Element).Value, Equals, 1)
+	c.Assert(s.queue.Peek(), Equals, 1)
 }
 
 func (s *LinkedBlockingQueueSuite) TestPutPanicsOnNil(c *C) {
@@ -157,6 +158,543 @@ func (s *LinkedBlockingQueueSuite) TestPutBlocks(c *C) {
 	c.Assert(thirdItem, Equals, 2)
 }
 
+func (s *LinkedBlockingQueueSuite) TestGetContextCancel(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		_, err := s.queue.GetContext(ctx)
+		done <- err
+	}()
+
+	cancel()
+	c.Assert(<-done, Equals, context.Canceled)
+	c.Assert(s.queue.Size(), Equals, uint64(0))
+}
+
+func (s *LinkedBlockingQueueSuite) TestGetContextDeadline(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	item, err := s.queue.GetContext(ctx)
+	c.Assert(item, IsNil)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *LinkedBlockingQueueSuite) TestGetContextReceives(c *C) {
+	done := make(chan interface{})
+
+	go func() {
+		item, _ := s.queue.GetContext(context.Background())
+		done <- item
+	}()
+
+	s.queue.Put(1)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *LinkedBlockingQueueSuite) TestPutContextCancel(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	res, err := s.queue.PutContext(ctx, 16)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+}
+
+func (s *LinkedBlockingQueueSuite) TestCancelledWaiterKeepsHandOff(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	received := make(chan interface{})
+
+	go func() {
+		_, err := s.queue.GetContext(ctx)
+		cancelled <- err
+	}()
+	go func() {
+		item, _ := s.queue.Get()
+		received <- item
+	}()
+
+	cancel()
+	c.Assert(<-cancelled, Equals, context.Canceled)
+
+	s.queue.Put(1)
+	c.Assert(<-received, Equals, 1)
+}
+
+func (s *LinkedBlockingQueueSuite) TestPollTimeout(c *C) {
+	item, err := s.queue.Poll(10 * time.Millisecond)
+	c.Assert(item, IsNil)
+	c.Assert(err, ErrorMatches, "ERROR_EMPTY: attempt to Get while Queue is Empty")
+
+	s.queue.Push(1)
+
+	item, err = s.queue.Poll(10 * time.Millisecond)
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+}
+
+func (s *LinkedBlockingQueueSuite) TestOfferTimeout(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	res, err := s.queue.OfferTimeout(16, 10*time.Millisecond)
+	c.Assert(res, Equals, false)
+	c.Assert(err, ErrorMatches, "ERROR_FULL: attempt to Put while Queue is Full")
+
+	go s.queue.Get()
+
+	res, err = s.queue.OfferTimeout(16, time.Second)
+	c.Assert(res, Equals, true)
+	c.Assert(err, IsNil)
+}
+
+func (s *LinkedBlockingQueueSuite) TestCloseRejectsWrites(c *C) {
+	s.queue.Push(1)
+	s.queue.Close()
+
+	c.Assert(s.queue.IsClosed(), Equals, true)
+	c.Assert(s.queue.Offer(2), Equals, false)
+
+	res, err := s.queue.Push(2)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorClosed)
+
+	res, err = s.queue.Put(2)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorClosed)
+
+	select {
+	case <-s.queue.Done():
+	default:
+		c.Error("Done channel not closed")
+	}
+}
+
+func (s *LinkedBlockingQueueSuite) TestCloseDrains(c *C) {
+	s.queue.Push(1)
+	s.queue.Push(2)
+	s.queue.Close()
+	s.queue.Close()
+
+	item, err := s.queue.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+
+	item, err = s.queue.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 2)
+
+	item, err = s.queue.Get()
+	c.Assert(item, IsNil)
+	c.Assert(err, Equals, ErrorClosed)
+}
+
+func (s *LinkedBlockingQueueSuite) TestCloseWakesWaiters(c *C) {
+	full, _ := NewArrayBlockingQueue(1)
+	full.Push(0)
+
+	n := 4
+	errs := make(chan error, 2*n)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := s.queue.Get()
+			errs <- err
+		}()
+		go func(i int) {
+			_, err := full.Put(i)
+			errs <- err
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	s.queue.Close()
+	full.Close()
+
+	for i := 0; i < 2*n; i++ {
+		c.Assert(<-errs, Equals, ErrorClosed)
+	}
+}
+
+func (s *LinkedBlockingQueueSuite) TestTyped(c *C) {
+	q, err := NewLinkedBlockingQueueOf[string](2)
+	c.Assert(err, IsNil)
+
+	q.Push("a")
+	q.Put("b")
+
+	res, err := q.Push("c")
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorFull)
+	c.Assert(q.Peek(), Equals, "a")
+
+	item, err := q.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, "a")
+
+	item, err = q.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, "b")
+
+	item, err = q.Pop()
+	c.Assert(item, Equals, "")
+	c.Assert(err, Equals, ErrorEmpty)
+	c.Assert(q.Peek(), Equals, "")
+}
+
+func (s *LinkedBlockingQueueSuite) TestDrainTo(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	dst := make([]interface{}, 4)
+	c.Assert(s.queue.DrainTo(dst, 8), Equals, 4)
+	c.Assert(dst, DeepEquals, []interface{}{0, 1, 2, 3})
+
+	dst = make([]interface{}, 16)
+	c.Assert(s.queue.DrainTo(dst, 3), Equals, 3)
+	c.Assert(dst[:3], DeepEquals, []interface{}{4, 5, 6})
+
+	c.Assert(s.queue.DrainTo(dst, 16), Equals, 3)
+	c.Assert(dst[:3], DeepEquals, []interface{}{7, 8, 9})
+	c.Assert(s.queue.DrainTo(dst, 16), Equals, 0)
+	c.Assert(s.queue.Size(), Equals, uint64(0))
+}
+
+func (s *LinkedBlockingQueueSuite) TestDrainToWakesProducers(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	n := 3
+	done := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			s.queue.Put(16 + i)
+			done <- true
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(s.queue.DrainTo(make([]interface{}, n), n), Equals, n)
+
+	for i := 0; i < n; i++ {
+		<-done
+	}
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+}
+
+func (s *LinkedBlockingQueueSuite) TestDrainToQueue(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	other, _ := NewLinkedBlockingQueue(4)
+	other.Push(-1)
+
+	c.Assert(s.queue.DrainToQueue(other, 2), Equals, 2)
+	c.Assert(s.queue.DrainToQueue(other, 10), Equals, 1)
+	c.Assert(s.queue.Size(), Equals, uint64(7))
+	c.Assert(s.queue.Peek(), Equals, 3)
+
+	for _, expected := range []interface{}{-1, 0, 1, 2} {
+		item, _ := other.Pop()
+		c.Assert(item, Equals, expected)
+	}
+}
+
+func (s *LinkedBlockingQueueSuite) TestDrainToQueueSelfPanics(c *C) {
+	defer func() {
+		if r := recover(); r == nil {
+			c.Errorf("TestDrainToQueueSelfPanics should have panicked!")
+		}
+	}()
+
+	s.queue.DrainToQueue(s.queue, 1)
+}
+
+func (s *LinkedBlockingQueueSuite) TestOfferAll(c *C) {
+	items := make([]interface{}, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	c.Assert(s.queue.OfferAll(items[:10]), Equals, 10)
+	c.Assert(s.queue.OfferAll(items[10:]), Equals, 6)
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+
+	for i := 0; i < 16; i += 1 {
+		item, _ := s.queue.Pop()
+		c.Assert(item, Equals, i)
+	}
+
+	s.queue.Close()
+	c.Assert(s.queue.OfferAll(items), Equals, 0)
+}
+
+func (s *LinkedBlockingQueueSuite) TestOfferBatch(c *C) {
+	items := []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	c.Assert(s.queue.OfferBatch(items), Equals, true)
+	c.Assert(s.queue.OfferBatch(items), Equals, false)
+	c.Assert(s.queue.Size(), Equals, uint64(10))
+	c.Assert(s.queue.OfferBatch(items[:6]), Equals, true)
+	c.Assert(s.queue.Capacity(), Equals, uint64(0))
+	c.Assert(s.queue.OfferBatch(nil), Equals, true)
+}
+
+func (s *LinkedBlockingQueueSuite) TestPutAllBlocks(c *C) {
+	items := make([]interface{}, 40)
+	for i := range items {
+		items[i] = i
+	}
+
+	done := make(chan int)
+	go func() {
+		n, err := s.queue.PutAll(items)
+		c.Check(err, IsNil)
+		done <- n
+	}()
+
+	for i := 0; i < len(items); i += 1 {
+		item, err := s.queue.Get()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, i)
+	}
+	c.Assert(<-done, Equals, len(items))
+}
+
+func (s *LinkedBlockingQueueSuite) TestPutAllClosed(c *C) {
+	items := make([]interface{}, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	done := make(chan error)
+	go func() {
+		n, err := s.queue.PutAll(items)
+		c.Check(n, Equals, 16)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.queue.Close()
+	c.Assert(<-done, Equals, ErrorClosed)
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+}
+
+func (s *LinkedBlockingQueueSuite) TestPutAllPanicsOnNil(c *C) {
+	defer func() {
+		if r := recover(); r == nil {
+			c.Errorf("TestPutAllPanicsOnNil should have panicked!")
+		}
+		c.Assert(s.queue.Size(), Equals, uint64(0))
+	}()
+
+	s.queue.PutAll([]interface{}{1, nil})
+}
+
+func (s *LinkedBlockingQueueSuite) TestUnbounded(c *C) {
+	q := NewUnboundedLinkedBlockingQueue()
+	c.Assert(q.Capacity(), Equals, Unbounded)
+
+	for i := 0; i < 10000; i += 1 {
+		res, err := q.Put(i)
+		c.Assert(res, Equals, true)
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(q.Size(), Equals, uint64(10000))
+	c.Assert(q.Capacity(), Equals, Unbounded)
+
+	for i := 0; i < 10000; i += 1 {
+		item, _ := q.Get()
+		c.Assert(item, Equals, i)
+	}
+
+	done := make(chan interface{})
+	go func() {
+		item, _ := q.Get()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Put(1)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *LinkedBlockingQueueSuite) TestUnboundedOfferBatch(c *C) {
+	q := NewUnboundedLinkedBlockingQueueOf[int]()
+
+	c.Assert(q.OfferBatch(make([]int, 1000)), Equals, true)
+	c.Assert(q.OfferAll(make([]int, 1000)), Equals, 1000)
+	c.Assert(q.Size(), Equals, uint64(2000))
+}
+
+func (s *LinkedBlockingQueueSuite) TestContains(c *C) {
+	c.Assert(s.queue.Contains(1), Equals, false)
+
+	for i := 0; i < 4; i += 1 {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.Contains(2), Equals, true)
+	c.Assert(s.queue.Contains(4), Equals, false)
+	c.Assert(s.queue.Contains("2"), Equals, false)
+}
+
+func (s *LinkedBlockingQueueSuite) TestRemove(c *C) {
+	for _, i := range []int{1, 2, 3, 2} {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.Remove(2), Equals, true)
+	c.Assert(s.queue.Remove(5), Equals, false)
+	c.Assert(s.queue.Size(), Equals, uint64(3))
+	c.Assert(s.queue.Snapshot(), DeepEquals, []interface{}{1, 3, 2})
+}
+
+func (s *LinkedBlockingQueueSuite) TestRemoveIf(c *C) {
+	// Move the head so the items wrap around the end of the store
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+		s.queue.Pop()
+	}
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	removed := s.queue.RemoveIf(func(item interface{}) bool {
+		return item.(int)%2 == 0
+	})
+	c.Assert(removed, Equals, 8)
+	c.Assert(s.queue.Size(), Equals, uint64(8))
+	c.Assert(s.queue.Capacity(), Equals, uint64(8))
+	c.Assert(s.queue.Peek(), Equals, 1)
+
+	// The freed slots are reused in order
+	for i := 16; i < 24; i += 1 {
+		res, err := s.queue.Push(i)
+		c.Assert(res, Equals, true)
+		c.Assert(err, IsNil)
+	}
+
+	for _, i := range []int{1, 3, 5, 7, 9, 11, 13, 15, 16, 17, 18, 19, 20, 21, 22, 23} {
+		item, _ := s.queue.Pop()
+		c.Assert(item, Equals, i)
+	}
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+	c.Assert(s.queue.RemoveIf(func(interface{}) bool { return true }), Equals, 0)
+}
+
+func (s *LinkedBlockingQueueSuite) TestRemoveIfWakesProducers(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	n := 3
+	done := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			s.queue.Put(16 + i)
+			done <- true
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(s.queue.RemoveIf(func(item interface{}) bool {
+		return item.(int) < n
+	}), Equals, n)
+
+	for i := 0; i < n; i++ {
+		<-done
+	}
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+	c.Assert(s.queue.Peek(), Equals, n)
+}
+
+func (s *LinkedBlockingQueueSuite) TestSetCapacityInvalid(c *C) {
+	c.Assert(s.queue.SetCapacity(0), Equals, ErrorCapacity)
+	c.Assert(s.queue.Capacity(), Equals, uint64(16))
+}
+
+func (s *LinkedBlockingQueueSuite) TestSetCapacityGrow(c *C) {
+	// Move the head so the items wrap around the end of the store
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+		s.queue.Pop()
+	}
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	n := 3
+	done := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			s.queue.Put(16)
+			done <- true
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(s.queue.SetCapacity(32), IsNil)
+
+	for i := 0; i < n; i++ {
+		<-done
+	}
+	c.Assert(s.queue.Size(), Equals, uint64(19))
+	c.Assert(s.queue.Capacity(), Equals, uint64(13))
+
+	for i := 0; i < 16; i += 1 {
+		item, _ := s.queue.Pop()
+		c.Assert(item, Equals, i)
+	}
+}
+
+func (s *LinkedBlockingQueueSuite) TestSetCapacityShrink(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.SetCapacity(4), IsNil)
+	c.Assert(s.queue.Size(), Equals, uint64(10))
+	c.Assert(s.queue.Capacity(), Equals, uint64(0))
+
+	res, err := s.queue.Push(10)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorFull)
+
+	done := make(chan bool)
+	go func() {
+		s.queue.Put(10)
+		done <- true
+	}()
+
+	// Producers wait until the queue drains below the new bound
+	for i := 0; i < 7; i += 1 {
+		item, _ := s.queue.Get()
+		c.Assert(item, Equals, i)
+	}
+	<-done
+
+	c.Assert(s.queue.Size(), Equals, uint64(4))
+	c.Assert(s.queue.Capacity(), Equals, uint64(0))
+	for i := 7; i < 11; i += 1 {
+		item, _ := s.queue.Pop()
+		c.Assert(item, Equals, i)
+	}
+	c.Assert(s.queue.Capacity(), Equals, uint64(4))
+}
+
 func (s *LinkedBlockingQueueSuite) BenchmarkPeek(c *C) {
 	for i := 0; i < c.N; i++ {
 		s.queue.Peek()
@@ -195,6 +733,20 @@ func (s *LinkedBlockingQueueSuite) BenchmarkPop(c *C) {
 	}
 }
 
+func (s *LinkedBlockingQueueSuite) BenchmarkDrainTo(c *C) {
+	q, _ := NewLinkedBlockingQueue(math.MaxUint16)
+	dst := make([]interface{}, 64)
+
+	c.ResetTimer()
+
+	for i := 0; i < c.N; i += len(dst) {
+		for j := range dst {
+			q.Push(j)
+		}
+		q.DrainTo(dst, len(dst))
+	}
+}
+
 func (s *LinkedBlockingQueueSuite) BenchmarkPushOverflow(c *C) {
 	for i := 0; i < c.N; i++ {
 		s.queue.Push(i)
@@ -0,0 +1,179 @@
+package blockingQueues
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives the events of a queue. The methods are called from
+// every producer and consumer, often while holding the queue lock, so
+// implementations must be concurrent safe and return quickly
+type Metrics interface {
+	// An element was added, leaving size elements in the queue
+	OnEnqueue(size uint64)
+
+	// An element was taken from the head, leaving size elements in the queue
+	OnDequeue(size uint64)
+
+	// An operation was refused with ErrorFull or ErrorEmpty
+	OnReject(err error)
+
+	// A consumer spent d blocked waiting for an element
+	OnGetWait(d time.Duration)
+
+	// A producer spent d blocked waiting for a free slot
+	OnPutWait(d time.Duration)
+}
+
+/**
+ * CounterMetrics is an in memory Metrics implementation
+ * keeping totals of the events it receives
+ */
+type CounterMetrics struct {
+	enqueued      uint64
+	dequeued      uint64
+	rejectedFull  uint64
+	rejectedEmpty uint64
+	peak          uint64
+	getWait       int64
+	putWait       int64
+}
+
+var _ Metrics = (*CounterMetrics)(nil)
+
+func NewCounterMetrics() *CounterMetrics {
+	return &CounterMetrics{}
+}
+
+func (m *CounterMetrics) OnEnqueue(size uint64) {
+	atomic.AddUint64(&m.enqueued, 1)
+
+	for {
+		var peak = atomic.LoadUint64(&m.peak)
+		if size <= peak || atomic.CompareAndSwapUint64(&m.peak, peak, size) {
+			return
+		}
+	}
+}
+
+func (m *CounterMetrics) OnDequeue(size uint64) {
+	atomic.AddUint64(&m.dequeued, 1)
+}
+
+func (m *CounterMetrics) OnReject(err error) {
+	switch err {
+	case ErrorFull:
+		atomic.AddUint64(&m.rejectedFull, 1)
+	case ErrorEmpty:
+		atomic.AddUint64(&m.rejectedEmpty, 1)
+	}
+}
+
+func (m *CounterMetrics) OnGetWait(d time.Duration) {
+	atomic.AddInt64(&m.getWait, int64(d))
+}
+
+func (m *CounterMetrics) OnPutWait(d time.Duration) {
+	atomic.AddInt64(&m.putWait, int64(d))
+}
+
+// Returns how many elements were added
+func (m *CounterMetrics) Enqueued() uint64 {
+	return atomic.LoadUint64(&m.enqueued)
+}
+
+// Returns how many elements were taken
+func (m *CounterMetrics) Dequeued() uint64 {
+	return atomic.LoadUint64(&m.dequeued)
+}
+
+// Returns how many operations were refused with ErrorFull
+func (m *CounterMetrics) RejectedFull() uint64 {
+	return atomic.LoadUint64(&m.rejectedFull)
+}
+
+// Returns how many operations were refused with ErrorEmpty
+func (m *CounterMetrics) RejectedEmpty() uint64 {
+	return atomic.LoadUint64(&m.rejectedEmpty)
+}
+
+// Returns the highest number of elements seen in the queue
+func (m *CounterMetrics) Peak() uint64 {
+	return atomic.LoadUint64(&m.peak)
+}
+
+// Returns the total time consumers spent blocked
+func (m *CounterMetrics) GetWait() time.Duration {
+	return time.Duration(atomic.LoadInt64(&m.getWait))
+}
+
+// Returns the total time producers spent blocked
+func (m *CounterMetrics) PutWait() time.Duration {
+	return time.Duration(atomic.LoadInt64(&m.putWait))
+}
+
+// Returns the time a goroutine started blocking, keeping start if it is
+// already set. Returns the zero time without metrics
+func startWait(m Metrics, start time.Time) time.Time {
+	if m != nil && start.IsZero() {
+		return time.Now()
+	}
+
+	return start
+}
+
+// Reports the time spent blocked since start, if the goroutine blocked at all
+func stopWait(m Metrics, start time.Time, put bool) {
+	if m == nil || start.IsZero() {
+		return
+	}
+
+	if put {
+		m.OnPutWait(time.Since(start))
+	} else {
+		m.OnGetWait(time.Since(start))
+	}
+}
+
+// Sets the Metrics receiving the events of the queue, nil to stop recording.
+// Operations blocked meanwhile may report their wait to the previous Metrics
+func (q *BlockingQueue[T]) SetMetrics(m Metrics) {
+	q.lock.Lock()
+	q.metrics = m
+	q.lock.Unlock()
+}
+
+// Returns the Metrics receiving the events of the queue, nil if none
+func (q *BlockingQueue[T]) Metrics() Metrics {
+	q.lock.Lock()
+	var res = q.metrics
+	q.lock.Unlock()
+
+	return res
+}
+
+// Reports a refused operation to the metrics
+func (q *BlockingQueue[T]) reject(err error) {
+	if q.metrics != nil {
+		q.metrics.OnReject(err)
+	}
+}
+
+// Sets the Metrics receiving the events of the buffer, nil to stop recording.
+// Operations in progress may report to the previous Metrics
+func (q *ConcurrentRingBuffer[T]) SetMetrics(m Metrics) {
+	if m == nil {
+		q.metrics.Store(nil)
+	} else {
+		q.metrics.Store(&m)
+	}
+}
+
+// Returns the Metrics receiving the events of the buffer, nil if none
+func (q *ConcurrentRingBuffer[T]) Metrics() Metrics {
+	if m := q.metrics.Load(); m != nil {
+		return *m
+	}
+
+	return nil
+}
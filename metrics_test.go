@@ -0,0 +1,218 @@
+package blockingQueues
+
+import (
+	. "gopkg.in/check.v1"
+	"runtime"
+	"sync"
+	"time"
+)
+
+type MetricsSuite struct {
+	queue   *BlockingQueue[interface{}]
+	metrics *CounterMetrics
+}
+
+var _ = Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) SetUpTest(c *C) {
+	s.queue, _ = NewArrayBlockingQueue(4)
+	s.metrics = NewCounterMetrics()
+	s.queue.SetMetrics(s.metrics)
+}
+
+func (s *MetricsSuite) TestCounts(c *C) {
+	for i := 0; i < 5; i++ {
+		s.queue.Push(i)
+	}
+	s.queue.Pop()
+	s.queue.Offer(5)
+	c.Assert(s.queue.OfferBatch([]interface{}{6, 7}), Equals, false)
+
+	c.Assert(s.metrics.Enqueued(), Equals, uint64(5))
+	c.Assert(s.metrics.Dequeued(), Equals, uint64(1))
+	c.Assert(s.metrics.RejectedFull(), Equals, uint64(2))
+	c.Assert(s.metrics.Peak(), Equals, uint64(4))
+
+	dst := make([]interface{}, 8)
+	c.Assert(s.queue.DrainTo(dst, 8), Equals, 4)
+	_, err := s.queue.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+
+	c.Assert(s.metrics.Dequeued(), Equals, uint64(5))
+	c.Assert(s.metrics.RejectedEmpty(), Equals, uint64(1))
+	c.Assert(s.metrics.Peak(), Equals, uint64(4))
+}
+
+func (s *MetricsSuite) TestTimeouts(c *C) {
+	_, err := s.queue.Poll(time.Millisecond)
+	c.Assert(err, Equals, ErrorEmpty)
+
+	s.queue.PutAll([]interface{}{1, 2, 3, 4})
+	_, err = s.queue.OfferTimeout(5, time.Millisecond)
+	c.Assert(err, Equals, ErrorFull)
+
+	c.Assert(s.metrics.RejectedEmpty(), Equals, uint64(1))
+	c.Assert(s.metrics.RejectedFull(), Equals, uint64(1))
+	c.Assert(s.metrics.GetWait() > 0, Equals, true)
+	c.Assert(s.metrics.PutWait() > 0, Equals, true)
+}
+
+func (s *MetricsSuite) TestGetWait(c *C) {
+	s.queue.Put(1)
+	s.queue.Get()
+	c.Assert(s.metrics.GetWait(), Equals, time.Duration(0))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.queue.Put(2)
+	}()
+
+	item, _ := s.queue.Get()
+	c.Assert(item, Equals, 2)
+	c.Assert(s.metrics.GetWait() >= 20*time.Millisecond, Equals, true)
+	c.Assert(s.metrics.PutWait(), Equals, time.Duration(0))
+}
+
+func (s *MetricsSuite) TestPutWait(c *C) {
+	for i := 0; i < 4; i++ {
+		s.queue.Put(i)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.queue.Get()
+	}()
+
+	s.queue.Put(4)
+	c.Assert(s.metrics.PutWait() >= 20*time.Millisecond, Equals, true)
+	c.Assert(s.metrics.GetWait(), Equals, time.Duration(0))
+}
+
+func (s *MetricsSuite) TestFair(c *C) {
+	q, _ := NewArrayBlockingQueueFair(1)
+	q.SetMetrics(s.metrics)
+	q.Put(1)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.Get()
+	}()
+
+	q.Put(2)
+	c.Assert(s.metrics.Enqueued(), Equals, uint64(2))
+	c.Assert(s.metrics.Dequeued(), Equals, uint64(1))
+	c.Assert(s.metrics.PutWait() >= 20*time.Millisecond, Equals, true)
+}
+
+func (s *MetricsSuite) TestRingBuffer(c *C) {
+	q, _ := NewConcurrentRingBuffer(4)
+	q.SetMetrics(s.metrics)
+
+	for i := 0; i < 4; i++ {
+		q.Put(i)
+	}
+	_, err := q.OfferTimeout(4, time.Millisecond)
+	c.Assert(err, Equals, ErrorFull)
+
+	for i := 0; i < 4; i++ {
+		q.Get()
+	}
+	_, err = q.Poll(time.Millisecond)
+	c.Assert(err, Equals, ErrorEmpty)
+
+	c.Assert(s.metrics.Enqueued(), Equals, uint64(4))
+	c.Assert(s.metrics.Dequeued(), Equals, uint64(4))
+	c.Assert(s.metrics.RejectedFull(), Equals, uint64(1))
+	c.Assert(s.metrics.RejectedEmpty(), Equals, uint64(1))
+	c.Assert(s.metrics.Peak(), Equals, uint64(4))
+	c.Assert(s.metrics.PutWait() > 0, Equals, true)
+	c.Assert(s.metrics.GetWait() > 0, Equals, true)
+}
+
+func (s *MetricsSuite) TestRingBufferNonBlocking(c *C) {
+	q, _ := NewConcurrentRingBuffer(2)
+	q.SetMetrics(s.metrics)
+
+	c.Assert(q.Offer(1), Equals, true)
+	c.Assert(q.Offer(2), Equals, true)
+	_, err := q.Push(3)
+	c.Assert(err, Equals, ErrorFull)
+
+	for i := 0; i < 2; i++ {
+		q.Pop()
+	}
+	_, err = q.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+
+	c.Assert(s.metrics.Enqueued(), Equals, uint64(2))
+	c.Assert(s.metrics.Dequeued(), Equals, uint64(2))
+	c.Assert(s.metrics.RejectedFull(), Equals, uint64(1))
+	c.Assert(s.metrics.RejectedEmpty(), Equals, uint64(1))
+	c.Assert(s.metrics.PutWait(), Equals, time.Duration(0))
+	c.Assert(s.metrics.GetWait(), Equals, time.Duration(0))
+}
+
+func (s *MetricsSuite) TestGetMetrics(c *C) {
+	c.Assert(s.queue.Metrics(), Equals, Metrics(s.metrics))
+	s.queue.SetMetrics(nil)
+	c.Assert(s.queue.Metrics(), IsNil)
+
+	q, _ := NewConcurrentRingBuffer(4)
+	c.Assert(q.Metrics(), IsNil)
+	q.SetMetrics(s.metrics)
+	c.Assert(q.Metrics(), Equals, Metrics(s.metrics))
+	q.SetMetrics(nil)
+	c.Assert(q.Metrics(), IsNil)
+}
+
+// Setting the metrics of queues in use is safe, run with -race
+func (s *MetricsSuite) TestSetMetricsWhileInUse(c *C) {
+	fair, _ := NewArrayBlockingQueueFair(4)
+	ring, _ := NewConcurrentRingBuffer(4)
+	var queues = []interface {
+		Interface[interface{}]
+		SetMetrics(Metrics)
+	}{s.queue, fair, ring}
+
+	for _, q := range queues {
+		q.SetMetrics(nil)
+
+		var done = make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					wg.Done()
+					return
+				default:
+				}
+				q.Put(i)
+				runtime.Gosched()
+			}
+		}()
+		go func() {
+			for {
+				select {
+				case <-done:
+					wg.Done()
+					return
+				default:
+				}
+				q.Pop()
+				runtime.Gosched()
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			q.SetMetrics(NewCounterMetrics())
+			runtime.Gosched()
+		}
+		close(done)
+
+		// Let a blocked producer out
+		q.Pop()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,487 @@
+package blockingQueues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+)
+
+// When the pages changed in a MmapStore are written back to its file
+type MsyncPolicy int
+
+const (
+	// Leave it to the operating system. Changes survive a crash of the
+	// process but may be lost if the machine goes down
+	MsyncNone MsyncPolicy = iota
+
+	// Schedule the write back after every change without waiting for it
+	MsyncAsync
+
+	// Wait for the write back after every change
+	MsyncSync
+)
+
+// Identifies MmapStore files, the last byte is the layout version
+var mmapMagic = [8]byte{'B', 'Q', 'M', 'M', 'A', 'P', 0, 1}
+
+// Header layout: magic, slot size, reserved, slots, read index, write index, count
+const (
+	mmapSlotSizeOffset   = 8
+	mmapSlotsOffset      = 16
+	mmapReadIndexOffset  = 24
+	mmapWriteIndexOffset = 32
+	mmapCountOffset      = 40
+	mmapHeaderSize       = 64
+)
+
+// Every slot starts with the length of its item
+const mmapSlotHeaderSize = 4
+
+/**
+ * MmapStore is a QueueStore of byte slices laying out the ArrayStore ring
+ * in a memory mapped file, with fixed size slots and a header tracking
+ * the read index, write index and count, so its items survive a restart
+ * and do not weigh on the Go heap. Items are copied in and out of the
+ * slots, and queues refuse the ones larger than the slot size.
+ * Adding and taking items survive a crash at any point, removing items
+ * with RemoveIf and changing the capacity do not
+ */
+type MmapStore struct {
+	file     *os.File
+	data     []byte
+	slotSize uint64
+	slots    uint64
+	policy   MsyncPolicy
+
+	// First error met while syncing or unmapping the file
+	err error
+
+	// Items left when closing, copied out of the unmapped file
+	closed *ArrayStore[[]byte]
+}
+
+// Opens the MmapStore file at path, creating it with the given number of
+// slots holding items of up to slotSize bytes. An existing file keeps its
+// number of slots, as changed by resizing, but must have the same slot size.
+// returns an error if the file cannot be mapped or has another layout
+func NewMmapStore(path string, slots uint64, slotSize uint32, policy MsyncPolicy) (*MmapStore, error) {
+	if slots < 1 || slotSize < 1 {
+		return nil, ErrorCapacity
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	var s = &MmapStore{
+		file:     f,
+		slotSize: uint64(slotSize),
+		slots:    slots,
+		policy:   policy,
+	}
+
+	if info.Size() == 0 {
+		err = s.create()
+	} else {
+		err = s.open(info.Size())
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+
+	return s, nil
+}
+
+// Returns the file size for the given number of slots,
+// or an error if it does not fit the address space
+func (s *MmapStore) fileSize(slots uint64) (int, error) {
+	var slotLen = mmapSlotHeaderSize + s.slotSize
+	if slots == 0 || slots > (math.MaxInt-mmapHeaderSize)/slotLen {
+		return 0, ErrorCapacity
+	}
+
+	return int(mmapHeaderSize + slots*slotLen), nil
+}
+
+// Sizes and maps a new file then writes its header
+func (s *MmapStore) create() error {
+	size, err := s.fileSize(s.slots)
+	if err != nil {
+		return err
+	}
+
+	if err = s.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+
+	if s.data, err = mmap(s.file, size); err != nil {
+		return err
+	}
+
+	copy(s.data, mmapMagic[:])
+	binary.LittleEndian.PutUint32(s.data[mmapSlotSizeOffset:], uint32(s.slotSize))
+	s.setHeader(mmapSlotsOffset, s.slots)
+	s.sync(0, len(s.data))
+
+	return s.err
+}
+
+// Maps an existing file and checks its header
+func (s *MmapStore) open(size int64) error {
+	if size < mmapHeaderSize {
+		return ErrorCorrupt
+	}
+
+	var header = make([]byte, mmapHeaderSize)
+	if _, err := s.file.ReadAt(header, 0); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(header[:len(mmapMagic)], mmapMagic[:]) {
+		return ErrorCorrupt
+	}
+	if uint64(binary.LittleEndian.Uint32(header[mmapSlotSizeOffset:])) != s.slotSize {
+		return ErrorLayout
+	}
+
+	s.slots = binary.LittleEndian.Uint64(header[mmapSlotsOffset:])
+	expected, err := s.fileSize(s.slots)
+	if err != nil || int64(expected) != size {
+		return ErrorCorrupt
+	}
+
+	if s.data, err = mmap(s.file, expected); err != nil {
+		return err
+	}
+
+	if s.ReadIndex() >= s.slots || s.WriteIndex() >= s.slots || s.header(mmapCountOffset) > s.slots {
+		munmap(s.data)
+		return ErrorCorrupt
+	}
+
+	return nil
+}
+
+func (s *MmapStore) header(offset int) uint64 {
+	return binary.LittleEndian.Uint64(s.data[offset:])
+}
+
+func (s *MmapStore) setHeader(offset int, value uint64) {
+	binary.LittleEndian.PutUint64(s.data[offset:], value)
+}
+
+// Returns the read index recorded in the header
+func (s *MmapStore) ReadIndex() uint64 {
+	return s.header(mmapReadIndexOffset)
+}
+
+// Returns the write index recorded in the header
+func (s *MmapStore) WriteIndex() uint64 {
+	return s.header(mmapWriteIndexOffset)
+}
+
+// Returns the number of items, derived from the read and write indexes
+// so that a change is committed by storing a single index. The count
+// recorded in the header only tells a full store from an empty one
+func (s *MmapStore) Count() uint64 {
+	var read, write = s.ReadIndex(), s.WriteIndex()
+	if read != write {
+		return (write + s.slots - read) % s.slots
+	}
+
+	if s.header(mmapCountOffset) == s.slots {
+		return s.slots
+	}
+
+	return 0
+}
+
+// Returns the bytes of the slot at pos
+func (s *MmapStore) slot(pos uint64) []byte {
+	var offset = mmapHeaderSize + pos*(mmapSlotHeaderSize+s.slotSize)
+	return s.data[offset : offset+mmapSlotHeaderSize+s.slotSize]
+}
+
+// Returns a copy of the item in the slot at pos
+func (s *MmapStore) read(pos uint64) []byte {
+	var slot = s.slot(pos)
+	var size = binary.LittleEndian.Uint32(slot)
+
+	return append([]byte{}, slot[mmapSlotHeaderSize:mmapSlotHeaderSize+size]...)
+}
+
+// Copies value into the slot at pos
+func (s *MmapStore) write(value []byte, pos uint64) {
+	var slot = s.slot(pos)
+	binary.LittleEndian.PutUint32(slot, uint32(len(value)))
+	copy(slot[mmapSlotHeaderSize:], value)
+}
+
+// Writes back the pages holding data[from:to] as the policy requires
+func (s *MmapStore) sync(from int, to int) {
+	if s.policy == MsyncNone || s.err != nil {
+		return
+	}
+
+	// msync needs a page aligned address
+	from -= from % os.Getpagesize()
+	s.err = msync(s.data[from:to], s.policy)
+}
+
+// Writes back the header and the slot at pos as the policy requires
+func (s *MmapStore) syncSlot(pos uint64) {
+	var offset = int(mmapHeaderSize + pos*(mmapSlotHeaderSize+s.slotSize))
+
+	s.sync(offset, offset+int(mmapSlotHeaderSize+s.slotSize))
+	s.sync(0, mmapHeaderSize)
+}
+
+func (s *MmapStore) checkItem(value []byte) ([]byte, error) {
+	if uint64(len(value)) > s.slotSize {
+		return nil, ErrorItemSize
+	}
+
+	return nil, nil
+}
+
+func (s *MmapStore) Set(value []byte, pos uint64) {
+	if s.closed != nil {
+		s.closed.Set(value, pos)
+		return
+	}
+
+	// The count goes first, it only matters once the write index moves
+	s.write(value, pos)
+	s.setHeader(mmapCountOffset, s.Count()+1)
+	s.setHeader(mmapWriteIndexOffset, (pos+1)%s.slots)
+	s.syncSlot(pos)
+}
+
+func (s *MmapStore) Get(pos uint64) []byte {
+	if s.closed != nil {
+		return s.closed.Get(pos)
+	}
+
+	return s.read(pos)
+}
+
+func (s *MmapStore) Remove(pos uint64) []byte {
+	if s.closed != nil {
+		return s.closed.Remove(pos)
+	}
+
+	// The slot is cleared last so it holds the item until the read index moves
+	var item = s.read(pos)
+	var count = s.Count() - 1
+	s.setHeader(mmapReadIndexOffset, (pos+1)%s.slots)
+	s.setHeader(mmapCountOffset, count)
+	binary.LittleEndian.PutUint32(s.slot(pos), 0)
+	s.syncSlot(pos)
+
+	return item
+}
+
+func (s *MmapStore) Items(pos uint64, count uint64) [][]byte {
+	if s.closed != nil {
+		return s.closed.Items(pos, count)
+	}
+
+	var res = make([][]byte, count)
+	for i := range res {
+		res[i] = s.read((pos + uint64(i)) % s.slots)
+	}
+
+	return res
+}
+
+// Compacts the kept items towards the head, preserving their order.
+// Not crash-safe: the slots are rewritten in place before the header
+// moves, so a crash in between may lose or repeat items
+func (s *MmapStore) RemoveIf(pos uint64, count uint64, pred func([]byte) bool) uint64 {
+	if s.closed != nil {
+		return s.closed.RemoveIf(pos, count, pred)
+	}
+
+	var kept = uint64(0)
+	for _, item := range s.Items(pos, count) {
+		if !pred(item) {
+			s.write(item, (pos+kept)%s.slots)
+			kept += 1
+		}
+	}
+
+	for i := kept; i < count; i += 1 {
+		binary.LittleEndian.PutUint32(s.slot((pos+i)%s.slots), 0)
+	}
+	s.setHeader(mmapWriteIndexOffset, (pos+kept)%s.slots)
+	s.setHeader(mmapCountOffset, kept)
+	s.sync(0, len(s.data))
+
+	return count - kept
+}
+
+// Remaps the file with the given number of slots, moving the items
+// to start at slot 0.
+// Not crash-safe: the slots are rewritten in place before the header
+// moves, so a crash in between may lose or repeat items.
+// returns an error if the file cannot be resized or mapped again,
+// in which case the store keeps its previous mapping
+func (s *MmapStore) Resize(pos uint64, count uint64, size uint64) error {
+	if s.closed != nil {
+		return s.closed.Resize(pos, count, size)
+	}
+
+	fileSize, err := s.fileSize(size)
+	if err != nil {
+		return err
+	}
+
+	var items = s.Items(pos, count)
+
+	if size != s.slots {
+		if err = s.remap(fileSize); err != nil {
+			return err
+		}
+		s.slots = size
+	}
+
+	for i, item := range items {
+		s.write(item, uint64(i))
+	}
+	s.setHeader(mmapSlotsOffset, s.slots)
+	s.setHeader(mmapReadIndexOffset, 0)
+	s.setHeader(mmapWriteIndexOffset, count%s.slots)
+	s.setHeader(mmapCountOffset, count)
+	s.sync(0, len(s.data))
+
+	return nil
+}
+
+// Maps the file again with the given size, growing it before mapping
+// and shrinking it after, so the previous mapping stays usable on failure.
+// The previous mapping is released once the new one is in place
+func (s *MmapStore) remap(fileSize int) error {
+	var previous = len(s.data)
+
+	if fileSize > previous {
+		if err := s.file.Truncate(int64(fileSize)); err != nil {
+			return err
+		}
+	}
+
+	data, err := mmap(s.file, fileSize)
+	if err == nil && fileSize < previous {
+		if err = s.file.Truncate(int64(fileSize)); err != nil {
+			munmap(data)
+		}
+	}
+	if err != nil {
+		if fileSize > previous {
+			s.file.Truncate(int64(previous))
+		}
+		return err
+	}
+
+	if err = munmap(s.data); err != nil && s.err == nil {
+		s.err = err
+	}
+	s.data = data
+
+	return nil
+}
+
+func (s *MmapStore) Size() uint64 {
+	if s.closed != nil {
+		return s.closed.Size()
+	}
+
+	return s.slots
+}
+
+// Returns the first error met while syncing or unmapping the file
+func (s *MmapStore) Err() error {
+	return s.err
+}
+
+// Writes back every change then unmaps and closes the file. The items
+// left are copied to memory so they can still be taken, but the file
+// is no longer updated and they are recovered again on restart.
+// A closed BlockingQueue closes its store only once drained
+func (s *MmapStore) Close() error {
+	if s.closed != nil {
+		return nil
+	}
+
+	var items = NewArrayStore[[]byte](s.slots)
+	var pos = s.ReadIndex()
+	for i := uint64(0); i < s.Count(); i += 1 {
+		items.Set(s.read(pos), pos)
+		pos = (pos + 1) % s.slots
+	}
+
+	var err = msync(s.data, MsyncSync)
+	if e := munmap(s.data); err == nil {
+		err = e
+	}
+	if e := s.file.Close(); err == nil {
+		err = e
+	}
+
+	s.data = nil
+	s.closed = items
+	if s.err == nil {
+		s.err = err
+	}
+
+	return err
+}
+
+// Creates an BlockingQueue of byte slices backed by a MmapStore at path,
+// with the given number of slots holding items of up to slotSize bytes,
+// recovering the items left by a previous run. Larger items are refused
+// with ErrorItemSize.
+// An existing file is resized to the capacity, keeping every recovered item.
+// Like SetCapacity and RemoveIf on the queue, resizing is not crash-safe.
+// The store is closed once the closed queue is drained.
+// returns an error if the capacity is less than 1 or the file cannot be mapped
+func NewMmapBlockingQueue(path string, capacity uint64, slotSize uint32, policy MsyncPolicy) (*BlockingQueue[[]byte], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
+
+	store, err := NewMmapStore(path, capacity, slotSize, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the recovered items even if the capacity was lowered since
+	var count = store.Count()
+	if size := max(capacity, count); size != store.Size() {
+		if err = store.Resize(store.ReadIndex(), count, size); err != nil {
+			store.Close()
+			return nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+		}
+	}
+
+	var q = newBlockingQueue[[]byte](store)
+	q.count = count
+	q.readIndex = store.ReadIndex()
+	q.writeIndex = store.WriteIndex()
+	q.capacity = capacity
+
+	return q, nil
+}
+
+var _ QueueStore[[]byte] = (*MmapStore)(nil)
+var _ io.Closer = (*MmapStore)(nil)
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package blockingQueues
+
+import "os"
+
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, ErrorUnsupported
+}
+
+func munmap(data []byte) error {
+	return ErrorUnsupported
+}
+
+func msync(data []byte, policy MsyncPolicy) error {
+	return ErrorUnsupported
+}
@@ -0,0 +1,302 @@
+//go:build linux || darwin
+
+package blockingQueues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	. "gopkg.in/check.v1"
+	"os"
+	"path/filepath"
+)
+
+type MmapStoreSuite struct {
+	path string
+}
+
+var _ = Suite(&MmapStoreSuite{})
+
+func (s *MmapStoreSuite) SetUpTest(c *C) {
+	s.path = filepath.Join(c.MkDir(), "queue.mmap")
+}
+
+func (s *MmapStoreSuite) open(c *C, capacity uint64) *BlockingQueue[[]byte] {
+	q, err := NewMmapBlockingQueue(s.path, capacity, 8, MsyncNone)
+	c.Assert(err, IsNil)
+
+	return q
+}
+
+func (s *MmapStoreSuite) items(q *BlockingQueue[[]byte]) []string {
+	var res = []string{}
+	for _, item := range q.Snapshot() {
+		res = append(res, string(item))
+	}
+
+	return res
+}
+
+func (s *MmapStoreSuite) TestInvalidCapacity(c *C) {
+	_, err := NewMmapBlockingQueue(s.path, 0, 8, MsyncNone)
+	c.Assert(err, Equals, ErrorCapacity)
+
+	_, err = NewMmapStore(s.path, 4, 0, MsyncNone)
+	c.Assert(err, Equals, ErrorCapacity)
+}
+
+func (s *MmapStoreSuite) TestRecover(c *C) {
+	q := s.open(c, 4)
+
+	// Move the head so the items wrap around the end of the file
+	for i := 0; i < 3; i++ {
+		q.Put([]byte("x"))
+		q.Get()
+	}
+	for _, item := range []string{"a", "bb", "ccc", "dddd"} {
+		q.Put([]byte(item))
+	}
+	q.Get()
+	q.Close()
+
+	q = s.open(c, 4)
+	c.Assert(q.Size(), Equals, uint64(3))
+	c.Assert(s.items(q), DeepEquals, []string{"bb", "ccc", "dddd"})
+
+	q.Put([]byte("e"))
+	for _, expected := range []string{"bb", "ccc", "dddd", "e"} {
+		item, _ := q.Get()
+		c.Assert(string(item), Equals, expected)
+	}
+	q.Close()
+
+	q = s.open(c, 4)
+	c.Assert(q.IsEmpty(), Equals, true)
+}
+
+func (s *MmapStoreSuite) TestItemsAreCopies(c *C) {
+	q := s.open(c, 4)
+
+	var item = []byte("abc")
+	q.Put(item)
+	item[0] = 'x'
+
+	res, _ := q.Get()
+	c.Assert(string(res), Equals, "abc")
+
+	// The slot gets reused without changing what was taken
+	q.Put([]byte("def"))
+	c.Assert(string(res), Equals, "abc")
+}
+
+func (s *MmapStoreSuite) TestItemLargerThanSlot(c *C) {
+	q := s.open(c, 4)
+
+	_, err := q.Put([]byte("123456789"))
+	c.Assert(err, Equals, ErrorItemSize)
+	c.Assert(q.Offer([]byte("123456789")), Equals, false)
+	q.Put([]byte("12345678"))
+	c.Assert(s.items(q), DeepEquals, []string{"12345678"})
+}
+
+func (s *MmapStoreSuite) TestRestoreLargerThanSlot(c *C) {
+	src, _ := NewArrayBlockingQueueOf[[]byte](4)
+	src.Put([]byte("a"))
+	src.Put([]byte("123456789"))
+
+	var buf bytes.Buffer
+	c.Assert(src.WriteSnapshot(&buf, GobCodec[[]byte]{}), IsNil)
+
+	q := s.open(c, 4)
+	var err = q.RestoreFrom(&buf, GobCodec[[]byte]{})
+
+	var snapshotErr *SnapshotError
+	c.Assert(errors.As(err, &snapshotErr), Equals, true)
+	c.Assert(errors.Is(err, ErrorItemSize), Equals, true)
+	c.Assert(q.Size(), Equals, uint64(0))
+}
+
+func (s *MmapStoreSuite) TestLayout(c *C) {
+	s.open(c, 4).Close()
+
+	_, err := NewMmapBlockingQueue(s.path, 4, 16, MsyncNone)
+	c.Assert(errors.Is(err, ErrorLayout), Equals, true)
+
+	c.Assert(os.WriteFile(s.path, []byte("not a queue file"), 0644), IsNil)
+	_, err = NewMmapBlockingQueue(s.path, 4, 8, MsyncNone)
+	c.Assert(errors.Is(err, ErrorCorrupt), Equals, true)
+}
+
+// Sets a header field of the closed file
+func (s *MmapStoreSuite) setHeader(c *C, offset int, value uint64) {
+	data, err := os.ReadFile(s.path)
+	c.Assert(err, IsNil)
+	binary.LittleEndian.PutUint64(data[offset:], value)
+	c.Assert(os.WriteFile(s.path, data, 0644), IsNil)
+}
+
+func (s *MmapStoreSuite) TestInterruptedChange(c *C) {
+	store, err := NewMmapStore(s.path, 4, 8, MsyncNone)
+	c.Assert(err, IsNil)
+	for i, item := range []string{"a", "b", "c"} {
+		store.Set([]byte(item), uint64(i))
+	}
+	store.Remove(0)
+	store.Close()
+
+	// A Set stopped before moving the write index did not happen
+	s.setHeader(c, mmapCountOffset, 3)
+	store, err = NewMmapStore(s.path, 4, 8, MsyncNone)
+	c.Assert(err, IsNil)
+	c.Assert(store.Count(), Equals, uint64(2))
+	store.Close()
+
+	// A Remove stopped after moving the read index did happen
+	s.setHeader(c, mmapReadIndexOffset, 2)
+	store, err = NewMmapStore(s.path, 4, 8, MsyncNone)
+	c.Assert(err, IsNil)
+	c.Assert(store.Count(), Equals, uint64(1))
+	c.Assert(string(store.Get(2)), Equals, "c")
+	store.Close()
+}
+
+func (s *MmapStoreSuite) TestFull(c *C) {
+	q := s.open(c, 2)
+	q.Put([]byte("a"))
+	q.Put([]byte("b"))
+	q.Get()
+	q.Put([]byte("c"))
+	q.Close()
+
+	q = s.open(c, 2)
+	c.Assert(s.items(q), DeepEquals, []string{"b", "c"})
+	q.Get()
+	q.Get()
+	q = s.open(c, 2)
+	c.Assert(s.items(q), DeepEquals, []string{})
+}
+
+func (s *MmapStoreSuite) TestClear(c *C) {
+	q := s.open(c, 4)
+	q.Put([]byte("a"))
+	q.Put([]byte("b"))
+	q.Clear()
+	q.Put([]byte("c"))
+	q.Close()
+
+	q = s.open(c, 4)
+	c.Assert(s.items(q), DeepEquals, []string{"c"})
+}
+
+func (s *MmapStoreSuite) TestRemoveIf(c *C) {
+	q := s.open(c, 4)
+	for _, item := range []string{"a", "b", "c", "d"} {
+		q.Put([]byte(item))
+	}
+	c.Assert(func() { q.Remove([]byte("b")) }, PanicMatches, "Uncomparable item")
+
+	removed := q.RemoveIf(func(item []byte) bool {
+		return item[0] == 'b' || item[0] == 'd'
+	})
+	c.Assert(removed, Equals, 2)
+	q.Put([]byte("e"))
+	q.Close()
+
+	q = s.open(c, 4)
+	c.Assert(s.items(q), DeepEquals, []string{"a", "c", "e"})
+}
+
+func (s *MmapStoreSuite) TestSetCapacity(c *C) {
+	q := s.open(c, 2)
+	q.Put([]byte("a"))
+	q.Put([]byte("b"))
+	q.Get()
+	q.Put([]byte("c"))
+
+	c.Assert(q.SetCapacity(4), IsNil)
+	q.Put([]byte("d"))
+	q.Close()
+
+	// Reopening with a lower capacity keeps the items
+	q = s.open(c, 2)
+	c.Assert(q.Capacity(), Equals, uint64(0))
+	c.Assert(q.store.Size(), Equals, uint64(3))
+	c.Assert(s.items(q), DeepEquals, []string{"b", "c", "d"})
+	q.Get()
+	q.Get()
+	c.Assert(q.Offer([]byte("e")), Equals, true)
+	c.Assert(q.Offer([]byte("f")), Equals, false)
+	q.Get()
+	q.Get()
+	q.Close()
+
+	// Reopening with a higher capacity grows the file
+	q = s.open(c, 100)
+	c.Assert(q.Capacity(), Equals, uint64(100))
+	for i := 0; i < 100; i++ {
+		c.Assert(q.Offer([]byte("x")), Equals, true)
+	}
+	c.Assert(q.Offer([]byte("x")), Equals, false)
+}
+
+func (s *MmapStoreSuite) TestSetCapacityFailure(c *C) {
+	q := s.open(c, 2)
+	q.Put([]byte("a"))
+	q.Put([]byte("b"))
+	q.Get()
+
+	// Too large to be backed by the file system or mapped
+	c.Assert(q.SetCapacity(1<<58), NotNil)
+	c.Assert(q.Err(), IsNil)
+
+	// The previous mapping is still in use
+	q.Put([]byte("c"))
+	c.Assert(s.items(q), DeepEquals, []string{"b", "c"})
+	q.Close()
+
+	info, err := os.Stat(s.path)
+	c.Assert(err, IsNil)
+	c.Assert(info.Size(), Equals, int64(mmapHeaderSize+2*(mmapSlotHeaderSize+8)))
+
+	q = s.open(c, 2)
+	c.Assert(s.items(q), DeepEquals, []string{"b", "c"})
+}
+
+func (s *MmapStoreSuite) TestMsyncPolicies(c *C) {
+	for _, policy := range []MsyncPolicy{MsyncAsync, MsyncSync} {
+		var path = filepath.Join(c.MkDir(), "queue.mmap")
+		q, err := NewMmapBlockingQueue(path, 4, 8, policy)
+		c.Assert(err, IsNil)
+
+		q.Put([]byte("a"))
+		q.Put([]byte("b"))
+		q.Get()
+		c.Assert(q.Err(), IsNil)
+		q.Close()
+
+		q, err = NewMmapBlockingQueue(path, 4, 8, policy)
+		c.Assert(err, IsNil)
+		item, _ := q.Get()
+		c.Assert(string(item), Equals, "b")
+	}
+}
+
+func (s *MmapStoreSuite) TestTakenAfterCloseRecorded(c *C) {
+	q := s.open(c, 4)
+	q.Put([]byte("a"))
+	q.Put([]byte("b"))
+	q.Close()
+
+	item, err := q.Get()
+	c.Assert(err, IsNil)
+	c.Assert(string(item), Equals, "a")
+	c.Assert(s.items(s.open(c, 4)), DeepEquals, []string{"b"})
+
+	// The file is unmapped once the queue is drained
+	item, err = q.Get()
+	c.Assert(err, IsNil)
+	c.Assert(string(item), Equals, "b")
+	c.Assert(q.store.(*MmapStore).closed, NotNil)
+	c.Assert(q.Err(), IsNil)
+	c.Assert(s.items(s.open(c, 4)), DeepEquals, []string{})
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin
+
+package blockingQueues
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Maps the first size bytes of f, shared with the file
+func mmap(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
+
+// Writes back the pages of data, which must start on a page boundary
+func msync(data []byte, policy MsyncPolicy) error {
+	var flags = syscall.MS_ASYNC
+	if policy == MsyncSync {
+		flags = syscall.MS_SYNC
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), uintptr(flags))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package blockingQueues
+
+import "container/heap"
+
+// Heap item along with its insertion order,
+// used to keep items of equal priority in FIFO order
+type heapEntry[T any] struct {
+	value T
+	seq   uint64
+}
+
+// Implements heap.Interface ordered by less then by insertion order
+type priorityHeap[T any] struct {
+	entries []heapEntry[T]
+	less    func(a, b T) bool
+}
+
+func (h *priorityHeap[T]) Len() int {
+	return len(h.entries)
+}
+
+func (h *priorityHeap[T]) Less(i, j int) bool {
+	if h.less(h.entries[i].value, h.entries[j].value) {
+		return true
+	}
+	if h.less(h.entries[j].value, h.entries[i].value) {
+		return false
+	}
+
+	return h.entries[i].seq < h.entries[j].seq
+}
+
+func (h *priorityHeap[T]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *priorityHeap[T]) Push(x any) {
+	h.entries = append(h.entries, x.(heapEntry[T]))
+}
+
+func (h *priorityHeap[T]) Pop() any {
+	var last = len(h.entries) - 1
+	var entry = h.entries[last]
+	h.entries[last] = heapEntry[T]{}
+	h.entries = h.entries[:last]
+
+	return entry
+}
+
+// HeapStore keeps its items ordered by priority instead of position.
+// Get and Remove always refer to the highest priority item
+type HeapStore[T any] struct {
+	heap     *priorityHeap[T]
+	seq      uint64
+	capacity uint64
+}
+
+// Creates a HeapStore where less reports whether a should be taken before b
+func NewHeapStore[T any](capacity uint64, less func(a, b T) bool) *HeapStore[T] {
+	return &HeapStore[T]{
+		heap:     &priorityHeap[T]{less: less},
+		capacity: capacity,
+	}
+}
+
+func (s *HeapStore[T]) Set(value T, pos uint64) {
+	heap.Push(s.heap, heapEntry[T]{value: value, seq: s.seq})
+	s.seq += 1
+}
+
+func (s *HeapStore[T]) Get(pos uint64) T {
+	return s.heap.entries[0].value
+}
+
+func (s *HeapStore[T]) Remove(pos uint64) T {
+	return heap.Pop(s.heap).(heapEntry[T]).value
+}
+
+// Returns the items in priority order, popping them off a copy of the heap
+func (s *HeapStore[T]) Items(pos uint64, count uint64) []T {
+	var h = &priorityHeap[T]{
+		entries: append([]heapEntry[T](nil), s.heap.entries...),
+		less:    s.heap.less,
+	}
+
+	var res = make([]T, 0, count)
+	for h.Len() > 0 && uint64(len(res)) < count {
+		res = append(res, heap.Pop(h).(heapEntry[T]).value)
+	}
+
+	return res
+}
+
+// Filters the entries then restores the heap ordering
+func (s *HeapStore[T]) RemoveIf(pos uint64, count uint64, pred func(T) bool) uint64 {
+	var entries = s.heap.entries
+	var kept = 0
+
+	for _, entry := range entries {
+		if !pred(entry.value) {
+			entries[kept] = entry
+			kept += 1
+		}
+	}
+	clear(entries[kept:])
+
+	s.heap.entries = entries[:kept]
+	heap.Init(s.heap)
+
+	return uint64(len(entries) - kept)
+}
+
+func (s *HeapStore[T]) Resize(pos uint64, count uint64, size uint64) error {
+	s.capacity = size
+	return nil
+}
+
+func (s HeapStore[T]) Size() uint64 {
+	return s.capacity
+}
+
+// Creates an BlockingQueue backed by a Heap with the given (fixed) capacity
+// where less reports whether a should be taken before b.
+// Items of equal priority are taken in FIFO order.
+// returns an error if the capacity is less than 1
+func NewPriorityBlockingQueue(capacity uint64, less func(a, b interface{}) bool) (*BlockingQueue[interface{}], error) {
+	return NewPriorityBlockingQueueOf[interface{}](capacity, less)
+}
+
+// Creates an BlockingQueue of T backed by a Heap with the given (fixed) capacity
+// where less reports whether a should be taken before b.
+// Items of equal priority are taken in FIFO order.
+// returns an error if the capacity is less than 1
+func NewPriorityBlockingQueueOf[T any](capacity uint64, less func(a, b T) bool) (*BlockingQueue[T], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
+
+	return newBlockingQueue[T](NewHeapStore[T](capacity, less)), nil
+}
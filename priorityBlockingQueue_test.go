@@ -0,0 +1,142 @@
+package blockingQueues
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type PriorityBlockingQueueSuite struct {
+	queue *BlockingQueue[interface{}]
+}
+
+var _ = Suite(&PriorityBlockingQueueSuite{})
+
+func (s *PriorityBlockingQueueSuite) SetUpTest(c *C) {
+	s.queue, _ = NewPriorityBlockingQueue(16, func(a, b interface{}) bool {
+		return a.(int) > b.(int)
+	})
+}
+
+func (s *PriorityBlockingQueueSuite) TestInvalidCapacity(c *C) {
+	_, err := NewPriorityBlockingQueueOf[int](0, func(a, b int) bool { return a < b })
+	c.Assert(err, ErrorMatches, "ERROR_CAPACITY: attempt to Create Queue with invalid Capacity")
+}
+
+func (s *PriorityBlockingQueueSuite) TestPriorityOrder(c *C) {
+	for _, i := range []int{3, 9, 1, 7, 5} {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.Peek(), Equals, 9)
+
+	for _, expected := range []int{9, 7, 5, 3, 1} {
+		item, err := s.queue.Pop()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, expected)
+	}
+
+	_, err := s.queue.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+}
+
+func (s *PriorityBlockingQueueSuite) TestEqualPriorityIsFIFO(c *C) {
+	type job struct {
+		priority int
+		name     string
+	}
+
+	q, _ := NewPriorityBlockingQueueOf[job](16, func(a, b job) bool {
+		return a.priority > b.priority
+	})
+
+	q.Push(job{1, "a"})
+	q.Push(job{2, "b"})
+	q.Push(job{1, "c"})
+	q.Push(job{2, "d"})
+	q.Push(job{1, "e"})
+
+	var names string
+	for !q.IsEmpty() {
+		item, _ := q.Pop()
+		names += item.name
+	}
+	c.Assert(names, Equals, "bdace")
+}
+
+func (s *PriorityBlockingQueueSuite) TestPushFull(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	res, err := s.queue.Push(17)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorFull)
+	c.Assert(s.queue.Peek(), Equals, 15)
+}
+
+func (s *PriorityBlockingQueueSuite) TestGetBlocks(c *C) {
+	done := make(chan interface{})
+
+	go func() {
+		item, _ := s.queue.Get()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.queue.Put(4)
+	c.Assert(<-done, Equals, 4)
+}
+
+func (s *PriorityBlockingQueueSuite) TestClear(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	s.queue.Clear()
+	c.Assert(s.queue.Size(), Equals, uint64(0))
+
+	s.queue.Push(1)
+	c.Assert(s.queue.Peek(), Equals, 1)
+}
+
+func (s *PriorityBlockingQueueSuite) TestDrainTo(c *C) {
+	for _, i := range []int{2, 8, 4, 6} {
+		s.queue.Push(i)
+	}
+
+	dst := make([]interface{}, 3)
+	c.Assert(s.queue.DrainTo(dst, 3), Equals, 3)
+	c.Assert(dst, DeepEquals, []interface{}{8, 6, 4})
+}
+
+func (s *PriorityBlockingQueueSuite) TestRemoveIf(c *C) {
+	for _, i := range []int{2, 7, 8, 3, 4, 6, 5} {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.Remove(8), Equals, true)
+	c.Assert(s.queue.Contains(8), Equals, false)
+	c.Assert(s.queue.RemoveIf(func(item interface{}) bool {
+		return item.(int)%2 == 1
+	}), Equals, 3)
+
+	dst := make([]interface{}, 4)
+	c.Assert(s.queue.DrainTo(dst, 4), Equals, 3)
+	c.Assert(dst[:3], DeepEquals, []interface{}{6, 4, 2})
+}
+
+func (s *PriorityBlockingQueueSuite) BenchmarkPush(c *C) {
+	q, _ := NewPriorityBlockingQueueOf[int](1024, func(a, b int) bool { return a < b })
+
+	for i := 0; i < c.N; i++ {
+		q.Push(i % 1024)
+		if q.Capacity() == 0 {
+			q.Clear()
+		}
+	}
+}
+
+func (s *PriorityBlockingQueueSuite) BenchmarkPut1to1(c *C) {
+	q, _ := NewPriorityBlockingQueue(1024, func(a, b interface{}) bool { return a.(int) < b.(int) })
+	benchmarkPut(c, 1, 1, q)
+}
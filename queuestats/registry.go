@@ -0,0 +1,264 @@
+// Package queuestats publishes statistics of named queues
+// through expvar and the Prometheus text exposition format
+package queuestats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/theodesp/blockingQueues"
+)
+
+var ErrorDuplicate = errors.New("ERROR_DUPLICATE: attempt to Register an already registered Queue name")
+
+// Queue is what a Registry needs from the queues it reports on
+type Queue interface {
+	Size() uint64
+	Capacity() uint64
+}
+
+// Queues recording their events through blockingQueues.Metrics
+type instrumented interface {
+	Metrics() blockingQueues.Metrics
+	SetMetrics(m blockingQueues.Metrics)
+}
+
+type entry struct {
+	queue Queue
+	stats *Stats
+}
+
+/**
+ * Registry keeps named queues along with their Stats.
+ * It is an expvar.Var and an http.Handler serving
+ * the Prometheus text exposition format
+ */
+type Registry struct {
+	lock    sync.Mutex
+	entries map[string]*entry
+}
+
+var _ expvar.Var = (*Registry)(nil)
+var _ http.Handler = (*Registry)(nil)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: make(map[string]*entry),
+	}
+}
+
+// Adds the queue under name. Queues having a SetMetrics method, even ones
+// already in use, record their events into the returned Stats, so throughput
+// and wait times are reported; others only report their size and capacity.
+// The Stats pass the events on to the Metrics the queue had, if any.
+// returns an error if the name is already registered
+func (r *Registry) Register(name string, q Queue) (*Stats, error) {
+	r.lock.Lock()
+
+	if _, ok := r.entries[name]; ok {
+		r.lock.Unlock()
+		return nil, ErrorDuplicate
+	}
+
+	var stats *Stats
+	if q, ok := q.(instrumented); ok {
+		stats = newStats(q.Metrics())
+		q.SetMetrics(stats)
+	} else {
+		stats = newStats(nil)
+	}
+	r.entries[name] = &entry{queue: q, stats: stats}
+	r.lock.Unlock()
+
+	return stats, nil
+}
+
+// Removes the queue registered under name, if any.
+// The queue records its events into the Metrics it had before Register
+// again, unless they were replaced since
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+
+	if e, ok := r.entries[name]; ok {
+		if q, ok := e.queue.(instrumented); ok && q.Metrics() == blockingQueues.Metrics(e.stats) {
+			q.SetMetrics(e.stats.next)
+		}
+		delete(r.entries, name)
+	}
+	r.lock.Unlock()
+}
+
+// Publishes the registry as an expvar variable under name.
+// Panics if the name is already used, like expvar.Publish
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, r)
+}
+
+// Statistics of a queue as exposed by expvar
+type queueVars struct {
+	Size          uint64            `json:"size"`
+	Capacity      float64           `json:"capacity"`
+	Enqueued      uint64            `json:"enqueued"`
+	Dequeued      uint64            `json:"dequeued"`
+	RejectedFull  uint64            `json:"rejected_full"`
+	RejectedEmpty uint64            `json:"rejected_empty"`
+	Peak          uint64            `json:"peak"`
+	GetWait       histogramSnapshot `json:"get_wait_seconds"`
+	PutWait       histogramSnapshot `json:"put_wait_seconds"`
+}
+
+// Returns the name sorted snapshots of every registered queue
+func (r *Registry) collect() ([]string, []queueVars) {
+	r.lock.Lock()
+
+	var names = make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var vars = make([]queueVars, len(names))
+	for i, name := range names {
+		var e = r.entries[name]
+		vars[i] = queueVars{
+			Size:          e.queue.Size(),
+			Capacity:      capacity(e.queue),
+			Enqueued:      e.stats.Enqueued(),
+			Dequeued:      e.stats.Dequeued(),
+			RejectedFull:  e.stats.RejectedFull(),
+			RejectedEmpty: e.stats.RejectedEmpty(),
+			Peak:          e.stats.Peak(),
+			GetWait:       e.stats.getWait.snapshot(),
+			PutWait:       e.stats.putWait.snapshot(),
+		}
+	}
+	r.lock.Unlock()
+
+	return names, vars
+}
+
+// Returns the remaining capacity of q, reporting Unbounded as -1
+// since JSON has no infinity
+func capacity(q Queue) float64 {
+	var res = q.Capacity()
+	if res == blockingQueues.Unbounded {
+		return -1
+	}
+
+	return float64(res)
+}
+
+// Returns the statistics of every registered queue as a JSON object
+// keyed by name. Implements expvar.Var
+func (r *Registry) String() string {
+	var names, vars = r.collect()
+
+	var res = make(map[string]queueVars, len(names))
+	for i, name := range names {
+		res[name] = vars[i]
+	}
+
+	var b, _ = json.Marshal(res)
+	return string(b)
+}
+
+// Writes the statistics of every registered queue in the
+// Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	var names, vars = r.collect()
+	var buf bytes.Buffer
+
+	var family = func(metric, kind, help string, value func(name string, v *queueVars)) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", metric, help, metric, kind)
+		for i, name := range names {
+			value(name, &vars[i])
+		}
+	}
+
+	var sample = func(metric, labels string, value float64) {
+		fmt.Fprintf(&buf, "%s{%s} %s\n", metric, labels, formatFloat(value))
+	}
+
+	family("blocking_queue_size", "gauge", "Number of elements in the queue.",
+		func(name string, v *queueVars) {
+			sample("blocking_queue_size", queueLabel(name), float64(v.Size))
+		})
+	family("blocking_queue_remaining_capacity", "gauge", "Number of elements that can be added before the queue is full.",
+		func(name string, v *queueVars) {
+			var value = v.Capacity
+			if value < 0 {
+				value = math.Inf(1)
+			}
+			sample("blocking_queue_remaining_capacity", queueLabel(name), value)
+		})
+	family("blocking_queue_peak_size", "gauge", "Highest number of elements seen in the queue.",
+		func(name string, v *queueVars) {
+			sample("blocking_queue_peak_size", queueLabel(name), float64(v.Peak))
+		})
+	family("blocking_queue_enqueued_total", "counter", "Elements added to the queue.",
+		func(name string, v *queueVars) {
+			sample("blocking_queue_enqueued_total", queueLabel(name), float64(v.Enqueued))
+		})
+	family("blocking_queue_dequeued_total", "counter", "Elements taken from the queue.",
+		func(name string, v *queueVars) {
+			sample("blocking_queue_dequeued_total", queueLabel(name), float64(v.Dequeued))
+		})
+	family("blocking_queue_rejected_total", "counter", "Operations refused because the queue was full or empty.",
+		func(name string, v *queueVars) {
+			sample("blocking_queue_rejected_total", queueLabel(name)+`,reason="full"`, float64(v.RejectedFull))
+			sample("blocking_queue_rejected_total", queueLabel(name)+`,reason="empty"`, float64(v.RejectedEmpty))
+		})
+
+	for _, h := range []struct {
+		metric, help string
+		get          func(v *queueVars) *histogramSnapshot
+	}{
+		{"blocking_queue_get_wait_seconds", "Time consumers spent blocked waiting for an element.",
+			func(v *queueVars) *histogramSnapshot { return &v.GetWait }},
+		{"blocking_queue_put_wait_seconds", "Time producers spent blocked waiting for a free slot.",
+			func(v *queueVars) *histogramSnapshot { return &v.PutWait }},
+	} {
+		family(h.metric, "histogram", h.help, func(name string, v *queueVars) {
+			var s = h.get(v)
+			for i, bound := range s.Bounds {
+				sample(h.metric+"_bucket", queueLabel(name)+`,le="`+formatFloat(bound)+`"`, float64(s.Buckets[i]))
+			}
+			sample(h.metric+"_bucket", queueLabel(name)+`,le="+Inf"`, float64(s.Count))
+			sample(h.metric+"_sum", queueLabel(name), s.Sum)
+			sample(h.metric+"_count", queueLabel(name), float64(s.Count))
+		})
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Serves WritePrometheus output. Implements http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WritePrometheus(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func queueLabel(name string) string {
+	return `queue="` + labelEscaper.Replace(name) + `"`
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,226 @@
+package queuestats
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	. "gopkg.in/check.v1"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theodesp/blockingQueues"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type RegistrySuite struct {
+	registry *Registry
+	queue    *blockingQueues.BlockingQueue[interface{}]
+}
+
+var _ = Suite(&RegistrySuite{})
+
+func (s *RegistrySuite) SetUpTest(c *C) {
+	s.registry = NewRegistry()
+	s.queue, _ = blockingQueues.NewArrayBlockingQueue(4)
+}
+
+func (s *RegistrySuite) prometheus(c *C) string {
+	var buf bytes.Buffer
+	c.Assert(s.registry.WritePrometheus(&buf), IsNil)
+
+	return buf.String()
+}
+
+func (s *RegistrySuite) TestRegister(c *C) {
+	stats, err := s.registry.Register("jobs", s.queue)
+	c.Assert(err, IsNil)
+
+	_, err = s.registry.Register("jobs", s.queue)
+	c.Assert(err, Equals, ErrorDuplicate)
+
+	s.queue.Push(1)
+	c.Assert(stats.Enqueued(), Equals, uint64(1))
+
+	s.registry.Unregister("jobs")
+	c.Assert(s.registry.String(), Equals, "{}")
+}
+
+func (s *RegistrySuite) TestRegisterKeepsMetrics(c *C) {
+	var metrics = blockingQueues.NewCounterMetrics()
+	s.queue.SetMetrics(metrics)
+
+	stats, _ := s.registry.Register("jobs", s.queue)
+	s.queue.Push(1)
+	s.queue.Pop()
+	s.queue.Pop()
+
+	for _, m := range []*blockingQueues.CounterMetrics{stats.CounterMetrics, metrics} {
+		c.Assert(m.Enqueued(), Equals, uint64(1))
+		c.Assert(m.Dequeued(), Equals, uint64(1))
+		c.Assert(m.RejectedEmpty(), Equals, uint64(1))
+	}
+}
+
+func (s *RegistrySuite) TestUnregisterRestoresMetrics(c *C) {
+	var metrics = blockingQueues.NewCounterMetrics()
+	s.queue.SetMetrics(metrics)
+
+	stats, _ := s.registry.Register("jobs", s.queue)
+	s.registry.Unregister("jobs")
+	c.Assert(s.queue.Metrics(), Equals, blockingQueues.Metrics(metrics))
+
+	s.queue.Push(1)
+	c.Assert(stats.Enqueued(), Equals, uint64(0))
+	c.Assert(metrics.Enqueued(), Equals, uint64(1))
+
+	// Metrics set after Register are kept
+	var replaced = blockingQueues.NewCounterMetrics()
+	s.registry.Register("jobs", s.queue)
+	s.queue.SetMetrics(replaced)
+	s.registry.Unregister("jobs")
+	c.Assert(s.queue.Metrics(), Equals, blockingQueues.Metrics(replaced))
+}
+
+// Registering queues in use is safe, run with -race
+func (s *RegistrySuite) TestRegisterLiveQueue(c *C) {
+	ring, _ := blockingQueues.NewConcurrentRingBuffer(4)
+	var queues = map[string]blockingQueues.Interface[interface{}]{"array": s.queue, "ring": ring}
+
+	for name, q := range queues {
+		var done = make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			for {
+				select {
+				case <-done:
+					wg.Done()
+					return
+				default:
+				}
+				q.Offer(1)
+				q.Pop()
+			}
+		}()
+
+		stats, err := s.registry.Register(name, q)
+		c.Assert(err, IsNil)
+
+		// Wait for the stats to record some events
+		for stats.Dequeued() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		close(done)
+		wg.Wait()
+		c.Assert(s.prometheus(c), Not(Equals), "")
+	}
+}
+
+func (s *RegistrySuite) TestExpvar(c *C) {
+	s.registry.Register("jobs", s.queue)
+	s.registry.Register("events", blockingQueues.NewUnboundedLinkedBlockingQueue())
+	s.registry.Publish("queuestats_test")
+
+	s.queue.Push(1)
+	s.queue.Push(2)
+	s.queue.Pop()
+	s.queue.Pop()
+	s.queue.Pop()
+
+	var vars map[string]queueVars
+	c.Assert(json.Unmarshal([]byte(expvar.Get("queuestats_test").String()), &vars), IsNil)
+
+	c.Assert(vars["jobs"].Size, Equals, uint64(0))
+	c.Assert(vars["jobs"].Capacity, Equals, float64(4))
+	c.Assert(vars["jobs"].Enqueued, Equals, uint64(2))
+	c.Assert(vars["jobs"].Dequeued, Equals, uint64(2))
+	c.Assert(vars["jobs"].RejectedEmpty, Equals, uint64(1))
+	c.Assert(vars["jobs"].Peak, Equals, uint64(2))
+	c.Assert(vars["events"].Capacity, Equals, float64(-1))
+}
+
+func (s *RegistrySuite) TestWaitHistogram(c *C) {
+	stats, _ := s.registry.Register("jobs", s.queue)
+
+	stats.OnGetWait(50 * time.Microsecond)
+	stats.OnGetWait(5 * time.Millisecond)
+	stats.OnGetWait(time.Minute)
+
+	var h = stats.getWait.snapshot()
+	c.Assert(h.Buckets, DeepEquals, []uint64{1, 1, 2, 2, 2, 2})
+	c.Assert(h.Count, Equals, uint64(3))
+	c.Assert(stats.GetWait(), Equals, time.Minute+5050*time.Microsecond)
+}
+
+func (s *RegistrySuite) TestWritePrometheus(c *C) {
+	s.registry.Register("jobs", s.queue)
+	s.registry.Register("events", blockingQueues.NewUnboundedLinkedBlockingQueue())
+
+	for i := 0; i < 5; i++ {
+		s.queue.Offer(i)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.queue.Get()
+	}()
+	s.queue.Put(5)
+
+	var out = s.prometheus(c)
+	for _, line := range []string{
+		"# TYPE blocking_queue_size gauge",
+		`blocking_queue_size{queue="jobs"} 4`,
+		`blocking_queue_size{queue="events"} 0`,
+		`blocking_queue_remaining_capacity{queue="jobs"} 0`,
+		`blocking_queue_remaining_capacity{queue="events"} +Inf`,
+		`blocking_queue_peak_size{queue="jobs"} 4`,
+		"# TYPE blocking_queue_enqueued_total counter",
+		`blocking_queue_enqueued_total{queue="jobs"} 5`,
+		`blocking_queue_dequeued_total{queue="jobs"} 1`,
+		`blocking_queue_rejected_total{queue="jobs",reason="full"} 1`,
+		`blocking_queue_rejected_total{queue="jobs",reason="empty"} 0`,
+		"# TYPE blocking_queue_put_wait_seconds histogram",
+		`blocking_queue_put_wait_seconds_bucket{queue="jobs",le="0.01"} 0`,
+		`blocking_queue_put_wait_seconds_bucket{queue="jobs",le="0.1"} 1`,
+		`blocking_queue_put_wait_seconds_bucket{queue="jobs",le="+Inf"} 1`,
+		`blocking_queue_put_wait_seconds_count{queue="jobs"} 1`,
+		`blocking_queue_get_wait_seconds_count{queue="jobs"} 0`,
+	} {
+		c.Check(strings.Contains(out, line+"\n"), Equals, true, Commentf("missing %q", line))
+	}
+
+	// Queues are listed by name
+	c.Assert(strings.Index(out, `{queue="events"}`) < strings.Index(out, `{queue="jobs"}`), Equals, true)
+}
+
+func (s *RegistrySuite) TestLabelEscaping(c *C) {
+	s.registry.Register("a \"b\"\\c\n", s.queue)
+
+	c.Assert(strings.Contains(s.prometheus(c), `blocking_queue_size{queue="a \"b\"\\c\n"} 0`), Equals, true)
+}
+
+func (s *RegistrySuite) TestNotInstrumented(c *C) {
+	q, _ := blockingQueues.NewTwoLockLinkedBlockingQueue(4)
+	q.Push(1)
+	s.registry.Register("jobs", q)
+
+	var out = s.prometheus(c)
+	c.Assert(strings.Contains(out, `blocking_queue_size{queue="jobs"} 1`), Equals, true)
+	c.Assert(strings.Contains(out, `blocking_queue_enqueued_total{queue="jobs"} 0`), Equals, true)
+}
+
+func (s *RegistrySuite) TestServeHTTP(c *C) {
+	s.registry.Register("jobs", s.queue)
+
+	var rec = httptest.NewRecorder()
+	s.registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	c.Assert(rec.Code, Equals, 200)
+	c.Assert(rec.Header().Get("Content-Type"), Equals, "text/plain; version=0.0.4; charset=utf-8")
+	c.Assert(rec.Body.String(), Equals, s.prometheus(c))
+}
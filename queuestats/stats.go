@@ -0,0 +1,132 @@
+package queuestats
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/theodesp/blockingQueues"
+)
+
+// Upper bounds in seconds of the wait time histogram buckets
+var WaitBuckets = []float64{0.0001, 0.001, 0.01, 0.1, 1, 10}
+
+/**
+ * Stats records the events of a registered queue, keeping the
+ * blockingQueues.CounterMetrics totals along with histograms
+ * of the time spent blocked in Get and Put.
+ * Events are also passed on to the Metrics the queue had before
+ */
+type Stats struct {
+	*blockingQueues.CounterMetrics
+
+	getWait *histogram
+	putWait *histogram
+
+	// The Metrics of the queue before it was registered, if any
+	next blockingQueues.Metrics
+}
+
+var _ blockingQueues.Metrics = (*Stats)(nil)
+
+func newStats(next blockingQueues.Metrics) *Stats {
+	return &Stats{
+		CounterMetrics: blockingQueues.NewCounterMetrics(),
+		getWait:        newHistogram(WaitBuckets),
+		putWait:        newHistogram(WaitBuckets),
+		next:           next,
+	}
+}
+
+func (s *Stats) OnEnqueue(size uint64) {
+	s.CounterMetrics.OnEnqueue(size)
+	if s.next != nil {
+		s.next.OnEnqueue(size)
+	}
+}
+
+func (s *Stats) OnDequeue(size uint64) {
+	s.CounterMetrics.OnDequeue(size)
+	if s.next != nil {
+		s.next.OnDequeue(size)
+	}
+}
+
+func (s *Stats) OnReject(err error) {
+	s.CounterMetrics.OnReject(err)
+	if s.next != nil {
+		s.next.OnReject(err)
+	}
+}
+
+func (s *Stats) OnGetWait(d time.Duration) {
+	s.CounterMetrics.OnGetWait(d)
+	s.getWait.observe(d)
+	if s.next != nil {
+		s.next.OnGetWait(d)
+	}
+}
+
+func (s *Stats) OnPutWait(d time.Duration) {
+	s.CounterMetrics.OnPutWait(d)
+	s.putWait.observe(d)
+	if s.next != nil {
+		s.next.OnPutWait(d)
+	}
+}
+
+// Fixed buckets histogram of durations, concurrent safe
+type histogram struct {
+	bounds []float64
+
+	// Observations per bucket, the last one is for values above every bound
+	counts []uint64
+
+	// Sum of the observations in nanoseconds
+	sum int64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	var i = 0
+	for i < len(h.bounds) && d.Seconds() > h.bounds[i] {
+		i += 1
+	}
+
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+// Cumulative view of a histogram as exposed by expvar and Prometheus
+type histogramSnapshot struct {
+	// Upper bounds in seconds, without the implicit +Inf one
+	Bounds []float64 `json:"bounds"`
+
+	// Observations less than or equal to each bound
+	Buckets []uint64 `json:"buckets"`
+
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+}
+
+func (h *histogram) snapshot() histogramSnapshot {
+	var res = histogramSnapshot{
+		Bounds:  h.bounds,
+		Buckets: make([]uint64, len(h.bounds)),
+	}
+
+	for i := range h.counts {
+		res.Count += atomic.LoadUint64(&h.counts[i])
+		if i < len(h.bounds) {
+			res.Buckets[i] = res.Count
+		}
+	}
+	res.Sum = time.Duration(atomic.LoadInt64(&h.sum)).Seconds()
+
+	return res
+}
@@ -0,0 +1,431 @@
+package blockingQueues
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Identifies snapshots
+var snapshotMagic = [7]byte{'B', 'Q', 'S', 'N', 'A', 'P', 0}
+
+// Version of the snapshot layout written by WriteSnapshot
+const snapshotVersion byte = 1
+
+// Snapshot flag set when every item is preceded by its deadline
+const snapshotDeadlines uint32 = 1
+
+// Header layout: magic, version, flags, count
+const snapshotHeaderSize = 20
+
+// SnapshotError reports a snapshot that cannot be restored.
+// Nothing is added to the queue when it is returned
+type SnapshotError struct {
+	// What is wrong with the snapshot
+	Reason string
+
+	// The underlying error, if any
+	Err error
+}
+
+func (e *SnapshotError) Error() string {
+	if e.Err != nil {
+		return "ERROR_SNAPSHOT: " + e.Reason + ": " + e.Err.Error()
+	}
+
+	return "ERROR_SNAPSHOT: " + e.Reason
+}
+
+func (e *SnapshotError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * Snapshot layout, little endian:
+ * header: magic, version byte, flags uint32, count uint64
+ * items: [deadline int64 unix nanoseconds] length uint32, encoded item
+ * trailer: crc32 IEEE of everything before it
+ */
+
+// Writes items in order, along with their deadlines if any
+func writeSnapshot[T any](w io.Writer, codec Codec[T], items []T, deadlines []time.Time) error {
+	var crc = crc32.NewIEEE()
+	var out = bufio.NewWriter(io.MultiWriter(w, crc))
+
+	var header [snapshotHeaderSize]byte
+	copy(header[:], snapshotMagic[:])
+	header[len(snapshotMagic)] = snapshotVersion
+	if deadlines != nil {
+		binary.LittleEndian.PutUint32(header[8:], snapshotDeadlines)
+	}
+	binary.LittleEndian.PutUint64(header[12:], uint64(len(items)))
+	out.Write(header[:])
+
+	var field [8]byte
+	for i, item := range items {
+		data, err := codec.Encode(item)
+		if err != nil {
+			return err
+		}
+
+		if deadlines != nil {
+			binary.LittleEndian.PutUint64(field[:], uint64(deadlines[i].UnixNano()))
+			out.Write(field[:])
+		}
+		binary.LittleEndian.PutUint32(field[:], uint32(len(data)))
+		out.Write(field[:4])
+		out.Write(data)
+	}
+
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(field[:], crc.Sum32())
+	_, err := w.Write(field[:4])
+
+	return err
+}
+
+// Reads the items of a snapshot, along with their deadlines if it has them.
+// Checks the whole snapshot before returning
+func readSnapshot[T any](r io.Reader, codec Codec[T]) ([]T, []time.Time, error) {
+	var crc = crc32.NewIEEE()
+	var in = io.TeeReader(bufio.NewReader(r), crc)
+
+	var header [snapshotHeaderSize]byte
+	if err := readFull(in, header[:]); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(header[:len(snapshotMagic)], snapshotMagic[:]) {
+		return nil, nil, &SnapshotError{Reason: "not a snapshot"}
+	}
+	if header[len(snapshotMagic)] != snapshotVersion {
+		return nil, nil, &SnapshotError{Reason: "unsupported version"}
+	}
+
+	var flags = binary.LittleEndian.Uint32(header[8:])
+	var count = binary.LittleEndian.Uint64(header[12:])
+
+	var items []T
+	var deadlines []time.Time
+	var field [8]byte
+
+	for i := uint64(0); i < count; i += 1 {
+		if flags&snapshotDeadlines != 0 {
+			if err := readFull(in, field[:]); err != nil {
+				return nil, nil, err
+			}
+			deadlines = append(deadlines, time.Unix(0, int64(binary.LittleEndian.Uint64(field[:]))))
+		}
+
+		if err := readFull(in, field[:4]); err != nil {
+			return nil, nil, err
+		}
+
+		var size = binary.LittleEndian.Uint32(field[:4])
+		if size > recordMaxSize {
+			return nil, nil, &SnapshotError{Reason: "item too large"}
+		}
+
+		// The buffer grows with what is actually read, not with the claimed size
+		var data bytes.Buffer
+		if n, err := io.CopyN(&data, in, int64(size)); n < int64(size) {
+			if err == nil || errors.Is(err, io.EOF) {
+				return nil, nil, &SnapshotError{Reason: "truncated", Err: io.ErrUnexpectedEOF}
+			}
+			return nil, nil, err
+		}
+
+		item, err := codec.Decode(data.Bytes())
+		if err != nil {
+			return nil, nil, &SnapshotError{Reason: "cannot decode item", Err: err}
+		}
+		if any(item) == nil {
+			return nil, nil, &SnapshotError{Reason: "null item"}
+		}
+		items = append(items, item)
+	}
+
+	return items, deadlines, checkSum(in, crc)
+}
+
+// Reads the trailer and compares it to the checksum of what was read
+func checkSum(in io.Reader, crc hash.Hash32) error {
+	var expected = crc.Sum32()
+
+	var trailer [4]byte
+	if err := readFull(in, trailer[:]); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(trailer[:]) != expected {
+		return &SnapshotError{Reason: "checksum mismatch"}
+	}
+
+	return nil
+}
+
+// Like io.ReadFull, reporting a short read as a truncated snapshot
+func readFull(r io.Reader, buf []byte) error {
+	_, err := io.ReadFull(r, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &SnapshotError{Reason: "truncated", Err: io.ErrUnexpectedEOF}
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Writes the elements of the queue, from head to tail, using codec.
+// Does not remove any element
+func (q *BlockingQueue[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	return writeSnapshot(w, codec, q.Snapshot(), nil)
+}
+
+// Adds the elements of a snapshot at the tail of the queue, in order.
+// Either every element is added or none is: returns a *SnapshotError if
+// the snapshot is corrupt or truncated or the store refuses an element,
+// and ErrorFull if the elements do not fit.
+// Does not block the current goroutine
+func (q *BlockingQueue[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, _, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	data, err := q.checkAll(items)
+	if err != nil {
+		return &SnapshotError{Reason: "item refused by the store", Err: err}
+	}
+
+	if !q.offerBatch(items, data) {
+		if q.IsClosed() {
+			return ErrorClosed
+		}
+		return ErrorFull
+	}
+
+	return nil
+}
+
+// Writes the elements of the queue, from head to tail, using codec.
+// Does not remove any element
+func (q *TwoLockLinkedBlockingQueue[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	q.putLock.Lock()
+	q.takeLock.Lock()
+
+	var items = make([]T, 0, q.Size())
+	for node := q.head.next; node != nil; node = node.next {
+		items = append(items, node.item)
+	}
+
+	q.takeLock.Unlock()
+	q.putLock.Unlock()
+
+	return writeSnapshot(w, codec, items, nil)
+}
+
+// Adds the elements of a snapshot at the tail of the queue, in order.
+// Either every element is added or none is: returns a *SnapshotError if
+// the snapshot is corrupt or truncated, and ErrorFull if the elements
+// do not fit. Does not block the current goroutine
+func (q *TwoLockLinkedBlockingQueue[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, _, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	q.putLock.Lock()
+	q.takeLock.Lock()
+
+	if uint64(len(items)) > q.Capacity() {
+		q.takeLock.Unlock()
+		q.putLock.Unlock()
+		return ErrorFull
+	}
+
+	for _, item := range items {
+		q.enqueue(item)
+	}
+	atomic.AddUint64(&q.count, uint64(len(items)))
+	q.notEmpty.Broadcast()
+
+	q.takeLock.Unlock()
+	q.putLock.Unlock()
+
+	return nil
+}
+
+// Writes the elements of the deque, from first to last, using codec.
+// Does not remove any element
+func (q *BlockingDeque[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	q.lock.Lock()
+	var items = q.store.Items()
+	q.lock.Unlock()
+
+	return writeSnapshot(w, codec, items, nil)
+}
+
+// Adds the elements of a snapshot at the end of the deque, in order.
+// Either every element is added or none is: returns a *SnapshotError if
+// the snapshot is corrupt or truncated, and ErrorFull if the elements
+// do not fit. Does not block the current goroutine
+func (q *BlockingDeque[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, _, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+
+	if uint64(len(items)) > q.store.Size()-q.count {
+		q.lock.Unlock()
+		return ErrorFull
+	}
+
+	for _, item := range items {
+		q.store.PushBack(item)
+	}
+	q.count += uint64(len(items))
+	q.notEmpty.Broadcast()
+	q.lock.Unlock()
+
+	return nil
+}
+
+// Writes the elements of the queue in deadline order, along with
+// their deadlines, using codec. Does not remove any element
+func (q *DelayQueue[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	q.lock.Lock()
+	var entries = q.store.Items(0, q.count)
+	q.lock.Unlock()
+
+	var items = make([]T, len(entries))
+	var deadlines = make([]time.Time, len(entries))
+	for i, entry := range entries {
+		items[i], deadlines[i] = entry.value, entry.deadline
+	}
+
+	return writeSnapshot(w, codec, items, deadlines)
+}
+
+// Adds the elements of a snapshot with their original deadlines.
+// Elements of snapshots of other queues are available right away.
+// Either every element is added or none is: returns a *SnapshotError
+// if the snapshot is corrupt or truncated
+func (q *DelayQueue[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, deadlines, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+
+	if q.closed {
+		q.lock.Unlock()
+		return ErrorClosed
+	}
+
+	var now = time.Now()
+	for i, item := range items {
+		var deadline = now
+		if deadlines != nil {
+			deadline = deadlines[i]
+		}
+		q.store.Set(delayed[T]{value: item, deadline: deadline}, 0)
+	}
+	q.count += uint64(len(items))
+	q.available.Broadcast()
+	q.lock.Unlock()
+
+	return nil
+}
+
+// Writes an empty snapshot, as a SynchronousQueue never holds elements
+func (q *SynchronousQueue[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	return writeSnapshot(w, codec, nil, nil)
+}
+
+// Checks the snapshot, returning ErrorFull if it has any element
+// as a SynchronousQueue cannot hold them
+func (q *SynchronousQueue[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, _, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	if len(items) > 0 {
+		return ErrorFull
+	}
+
+	return nil
+}
+
+// Writes the elements of the queue, from head to tail, using codec.
+// Pending transfers are written as plain elements.
+// Does not remove any element
+func (q *TransferQueue[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	q.lock.Lock()
+
+	var items = make([]T, 0, q.count)
+	for _, node := range q.store.Items(0, q.nodes) {
+		if !node.cancelled {
+			items = append(items, node.item)
+		}
+	}
+	q.lock.Unlock()
+
+	return writeSnapshot(w, codec, items, nil)
+}
+
+// Adds the elements of a snapshot at the tail of the queue, in order.
+// Either every element is added or none is: returns a *SnapshotError
+// if the snapshot is corrupt or truncated
+func (q *TransferQueue[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, _, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	q.lock.Lock()
+	for _, item := range items {
+		q.push(&transferNode[T]{item: item})
+	}
+	q.lock.Unlock()
+
+	return nil
+}
+
+// Writes the written elements of the buffer, from head to tail,
+// using codec. Like Snapshot, elements taken meanwhile are skipped
+func (q *ConcurrentRingBuffer[T]) WriteSnapshot(w io.Writer, codec Codec[T]) error {
+	return writeSnapshot(w, codec, q.Snapshot(), nil)
+}
+
+// Adds the elements of a snapshot at the tail of the buffer, in order.
+// Either every element is added or none is: returns a *SnapshotError if
+// the snapshot is corrupt or truncated, and ErrorFull if the elements
+// do not fit. Only call when no other goroutine uses the buffer
+func (q *ConcurrentRingBuffer[T]) RestoreFrom(r io.Reader, codec Codec[T]) error {
+	items, _, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+
+	if uint64(len(items)) > q.Capacity() {
+		return ErrorFull
+	}
+
+	for _, item := range items {
+		q.Put(item)
+	}
+
+	return nil
+}
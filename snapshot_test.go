@@ -0,0 +1,250 @@
+package blockingQueues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	. "gopkg.in/check.v1"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"time"
+)
+
+type SnapshotSuite struct{}
+
+var _ = Suite(&SnapshotSuite{})
+
+type snapshotItem struct {
+	Name  string
+	Count int
+}
+
+func init() {
+	gob.Register(snapshotItem{})
+}
+
+// Writes a snapshot of 1, 2, 3
+func (s *SnapshotSuite) snapshot(c *C) []byte {
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	q.Put(1)
+	q.Put(2)
+	q.Put(3)
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, intCodec{}), IsNil)
+
+	return buf.Bytes()
+}
+
+func (s *SnapshotSuite) TestRoundTrip(c *C) {
+	var data = s.snapshot(c)
+
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	q.Put(0)
+	c.Assert(q.RestoreFrom(bytes.NewReader(data), intCodec{}), IsNil)
+	c.Assert(q.Snapshot(), DeepEquals, []int{0, 1, 2, 3})
+}
+
+func (s *SnapshotSuite) TestRestoreFull(c *C) {
+	q, _ := NewArrayBlockingQueueOf[int](2)
+	c.Assert(q.RestoreFrom(bytes.NewReader(s.snapshot(c)), intCodec{}), Equals, ErrorFull)
+	c.Assert(q.Size(), Equals, uint64(0))
+
+	q.Close()
+	c.Assert(q.RestoreFrom(bytes.NewReader(s.snapshot(c)), intCodec{}), Equals, ErrorClosed)
+}
+
+func (s *SnapshotSuite) TestEveryQueue(c *C) {
+	var data = s.snapshot(c)
+
+	linked := NewUnboundedLinkedBlockingQueueOf[int]()
+	twoLock, _ := NewTwoLockLinkedBlockingQueueOf[int](4)
+	arrayDeque, _ := NewArrayBlockingDequeOf[int](4)
+	linkedDeque, _ := NewLinkedBlockingDequeOf[int](4)
+	transfer := NewTransferQueueOf[int]()
+	ring, _ := NewConcurrentRingBufferOf[int](8)
+
+	var queues = []interface {
+		Interface[int]
+		WriteSnapshot(io.Writer, Codec[int]) error
+		RestoreFrom(io.Reader, Codec[int]) error
+	}{linked, twoLock, arrayDeque, linkedDeque, transfer}
+
+	for _, q := range queues {
+		c.Assert(q.RestoreFrom(bytes.NewReader(data), intCodec{}), IsNil)
+		c.Assert(q.Size(), Equals, uint64(3))
+
+		var buf bytes.Buffer
+		c.Assert(q.WriteSnapshot(&buf, intCodec{}), IsNil)
+		c.Assert(buf.Bytes(), DeepEquals, data)
+
+		for i := 1; i <= 3; i++ {
+			item, err := q.Get()
+			c.Assert(err, IsNil)
+			c.Assert(item, Equals, i)
+		}
+	}
+
+	c.Assert(ring.RestoreFrom(bytes.NewReader(data), intCodec{}), IsNil)
+	var buf bytes.Buffer
+	c.Assert(ring.WriteSnapshot(&buf, intCodec{}), IsNil)
+	c.Assert(buf.Bytes(), DeepEquals, data)
+}
+
+func (s *SnapshotSuite) TestRestoreTwoLockFull(c *C) {
+	q, _ := NewTwoLockLinkedBlockingQueueOf[int](3)
+	q.Put(0)
+	c.Assert(q.RestoreFrom(bytes.NewReader(s.snapshot(c)), intCodec{}), Equals, ErrorFull)
+	c.Assert(q.Size(), Equals, uint64(1))
+}
+
+func (s *SnapshotSuite) TestRestoreWakesConsumers(c *C) {
+	q, _ := NewTwoLockLinkedBlockingQueueOf[int](4)
+	var done = make(chan int)
+	go func() {
+		item, _ := q.Get()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(q.RestoreFrom(bytes.NewReader(s.snapshot(c)), intCodec{}), IsNil)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *SnapshotSuite) TestDelayQueueKeepsDeadlines(c *C) {
+	q := NewDelayQueueOf[int]()
+	var now = time.Now()
+	q.PutAt(2, now.Add(time.Hour))
+	q.PutAt(1, now.Add(-time.Second))
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, intCodec{}), IsNil)
+
+	r := NewDelayQueueOf[int]()
+	c.Assert(r.RestoreFrom(&buf, intCodec{}), IsNil)
+	c.Assert(r.Size(), Equals, uint64(2))
+
+	item, err := r.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+
+	_, err = r.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+
+	// Items of other queues are due right away
+	c.Assert(r.RestoreFrom(bytes.NewReader(s.snapshot(c)), intCodec{}), IsNil)
+	item, _ = r.Pop()
+	c.Assert(item, Equals, 1)
+}
+
+func (s *SnapshotSuite) TestSynchronousQueue(c *C) {
+	q := NewSynchronousQueueOf[int](false)
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, intCodec{}), IsNil)
+	c.Assert(q.RestoreFrom(&buf, intCodec{}), IsNil)
+	c.Assert(q.RestoreFrom(bytes.NewReader(s.snapshot(c)), intCodec{}), Equals, ErrorFull)
+}
+
+func (s *SnapshotSuite) TestCorrupt(c *C) {
+	var data = s.snapshot(c)
+
+	for i := range data {
+		var corrupt = bytes.Clone(data)
+		corrupt[i] ^= 0xff
+
+		q, _ := NewArrayBlockingQueueOf[int](4)
+		var err = q.RestoreFrom(bytes.NewReader(corrupt), intCodec{})
+
+		var snapshotErr *SnapshotError
+		c.Assert(errors.As(err, &snapshotErr), Equals, true, Commentf("byte %d: %v", i, err))
+		c.Assert(q.Size(), Equals, uint64(0))
+	}
+}
+
+func (s *SnapshotSuite) TestTruncated(c *C) {
+	var data = s.snapshot(c)
+
+	for i := 0; i < len(data); i++ {
+		q, _ := NewArrayBlockingQueueOf[int](4)
+		var err = q.RestoreFrom(bytes.NewReader(data[:i]), intCodec{})
+
+		var snapshotErr *SnapshotError
+		c.Assert(errors.As(err, &snapshotErr), Equals, true, Commentf("length %d: %v", i, err))
+		c.Assert(errors.Is(err, io.ErrUnexpectedEOF), Equals, true)
+		c.Assert(q.Size(), Equals, uint64(0))
+	}
+}
+
+func (s *SnapshotSuite) TestClaimedSizeNotAllocated(c *C) {
+	// A single item claiming to be 512MB followed by nothing
+	var data = s.snapshot(c)[:snapshotHeaderSize+4]
+	binary.LittleEndian.PutUint64(data[12:], 1)
+	binary.LittleEndian.PutUint32(data[snapshotHeaderSize:], 1<<29)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	var err = q.RestoreFrom(bytes.NewReader(data), intCodec{})
+	c.Assert(errors.Is(err, io.ErrUnexpectedEOF), Equals, true)
+
+	runtime.ReadMemStats(&after)
+	c.Assert(after.TotalAlloc-before.TotalAlloc < 1<<20, Equals, true)
+}
+
+func (s *SnapshotSuite) TestEncodeError(c *C) {
+	q, _ := NewArrayBlockingQueueOf[int](4)
+	q.Put(-1)
+
+	c.Assert(q.WriteSnapshot(io.Discard, failingCodec{}), ErrorMatches, "negative")
+}
+
+func (s *SnapshotSuite) TestGobCodec(c *C) {
+	q := NewUnboundedLinkedBlockingQueue()
+	q.Put(snapshotItem{"a", 1})
+	q.Put(snapshotItem{"b", 2})
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, GobCodec[interface{}]{}), IsNil)
+
+	r := NewUnboundedLinkedBlockingQueue()
+	c.Assert(r.RestoreFrom(&buf, GobCodec[interface{}]{}), IsNil)
+	c.Assert(r.Snapshot(), DeepEquals, []interface{}{snapshotItem{"a", 1}, snapshotItem{"b", 2}})
+}
+
+func (s *SnapshotSuite) TestJSONCodec(c *C) {
+	q := NewUnboundedLinkedBlockingQueueOf[snapshotItem]()
+	q.Put(snapshotItem{"a", 1})
+	q.Put(snapshotItem{"b", 2})
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, JSONCodec[snapshotItem]{}), IsNil)
+
+	r := NewUnboundedLinkedBlockingQueueOf[snapshotItem]()
+	c.Assert(r.RestoreFrom(&buf, JSONCodec[snapshotItem]{}), IsNil)
+	c.Assert(r.Snapshot(), DeepEquals, []snapshotItem{{"a", 1}, {"b", 2}})
+}
+
+func (s *SnapshotSuite) TestNullItem(c *C) {
+	q := NewUnboundedLinkedBlockingQueue()
+	q.Put(1)
+
+	var buf bytes.Buffer
+	c.Assert(q.WriteSnapshot(&buf, JSONCodec[interface{}]{}), IsNil)
+
+	// Replace the item with null, keeping the checksum valid
+	var data = buf.Bytes()
+	data = append(data[:snapshotHeaderSize], 4, 0, 0, 0, 'n', 'u', 'l', 'l')
+	var snapshot bytes.Buffer
+	snapshot.Write(data)
+	var crc [4]byte
+	binary.LittleEndian.PutUint32(crc[:], crc32.ChecksumIEEE(data))
+	snapshot.Write(crc[:])
+
+	var err = q.RestoreFrom(&snapshot, JSONCodec[interface{}]{})
+	c.Assert(err, FitsTypeOf, &SnapshotError{})
+	c.Assert(q.Size(), Equals, uint64(1))
+}
@@ -0,0 +1,241 @@
+package blockingQueues
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// A goroutine blocked waiting for its counterpart to hand off an item
+type syncWaiter[T any] struct {
+	// The item handed off, set by the producer
+	item T
+
+	// What the store needs for item, as checked by the producer
+	data []byte
+
+	// Set when the waiter is released without a hand-off
+	err error
+
+	// Closed once the waiter has been matched or released
+	matched chan struct{}
+
+	// The waiter position in its list, nil once matched
+	elem *list.Element
+}
+
+// Adds waiter to waiters and blocks until it gets matched or ctx is done.
+// Call only when holding lock, returns with the lock released.
+func awaitMatch[T any](ctx context.Context, lock *sync.Mutex, waiters *list.List, waiter *syncWaiter[T]) error {
+	waiter.elem = waiters.PushBack(waiter)
+	lock.Unlock()
+
+	select {
+	case <-waiter.matched:
+		return waiter.err
+	case <-ctx.Done():
+	}
+
+	lock.Lock()
+	if waiter.elem == nil {
+		// Matched while we were giving up, wait for the hand-off to complete
+		lock.Unlock()
+		<-waiter.matched
+
+		return waiter.err
+	}
+	waiters.Remove(waiter.elem)
+	lock.Unlock()
+
+	return ctx.Err()
+}
+
+/**
+ * SynchronousQueue is a zero capacity queue where each Put
+ * must wait for a Get and vice versa
+ */
+
+type SynchronousQueue[T any] struct {
+	// Main lock guarding all access
+	lock *sync.Mutex
+
+	// Whether waiters are matched in FIFO (fair) or LIFO (unfair) order
+	fair bool
+
+	// Producers blocked in Put
+	producers *list.List
+
+	// Consumers blocked in Get
+	consumers *list.List
+}
+
+// Creates a SynchronousQueue. A fair queue matches waiters in FIFO order,
+// an unfair one in LIFO order
+func NewSynchronousQueue(fair bool) *SynchronousQueue[interface{}] {
+	return NewSynchronousQueueOf[interface{}](fair)
+}
+
+// Creates a SynchronousQueue of T. A fair queue matches waiters in FIFO order,
+// an unfair one in LIFO order
+func NewSynchronousQueueOf[T any](fair bool) *SynchronousQueue[T] {
+	return &SynchronousQueue[T]{
+		lock:      new(sync.Mutex),
+		fair:      fair,
+		producers: list.New(),
+		consumers: list.New(),
+	}
+}
+
+var _ Interface[interface{}] = (*SynchronousQueue[interface{}])(nil)
+
+// Removes the next waiter to match from waiters, if any.
+// Call only when holding lock.
+func (q *SynchronousQueue[T]) match(waiters *list.List) *syncWaiter[T] {
+	if waiters.Len() == 0 {
+		return nil
+	}
+
+	var elem = waiters.Back()
+	if q.fair {
+		elem = waiters.Front()
+	}
+
+	var waiter = waiters.Remove(elem).(*syncWaiter[T])
+	waiter.elem = nil
+
+	return waiter
+}
+
+// Size always returns 0 as the queue holds no items
+func (q *SynchronousQueue[T]) Size() uint64 {
+	return 0
+}
+
+// Capacity always returns 0 as the queue holds no items
+func (q *SynchronousQueue[T]) Capacity() uint64 {
+	return 0
+}
+
+// IsEmpty always returns true as the queue holds no items
+func (q *SynchronousQueue[T]) IsEmpty() bool {
+	return true
+}
+
+// Clear has no effect as the queue holds no items
+func (q *SynchronousQueue[T]) Clear() {
+}
+
+// Peek always returns the zero value as the queue holds no items
+func (q *SynchronousQueue[T]) Peek() T {
+	var zero T
+	return zero
+}
+
+// Hands the element to a consumer waiting in Get if there is one,
+// returning ErrorFull otherwise.
+// Does not block the current goroutine
+func (q *SynchronousQueue[T]) Push(item T) (bool, error) {
+	if q.Offer(item) {
+		return true, nil
+	} else {
+		return false, ErrorFull
+	}
+}
+
+// Hands the element to a consumer waiting in Get if there is one,
+// returning true upon success and false otherwise.
+// Does not block the current goroutine
+func (q *SynchronousQueue[T]) Offer(item T) bool {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.lock.Lock()
+	var consumer = q.match(q.consumers)
+	q.lock.Unlock()
+
+	if consumer == nil {
+		return false
+	}
+	consumer.item = item
+	close(consumer.matched)
+
+	return true
+}
+
+// Takes the element of a producer waiting in Put if there is one,
+// returning ErrorEmpty otherwise.
+// Does not block the current goroutine
+func (q *SynchronousQueue[T]) Pop() (T, error) {
+	q.lock.Lock()
+	var producer = q.match(q.producers)
+	q.lock.Unlock()
+
+	if producer == nil {
+		var zero T
+		return zero, ErrorEmpty
+	}
+	close(producer.matched)
+
+	return producer.item, nil
+}
+
+// Takes an element from a producer.
+// It blocks the current goroutine until a producer hands one over
+func (q *SynchronousQueue[T]) Get() (T, error) {
+	return q.GetContext(context.Background())
+}
+
+// Hands the element to a consumer.
+// It blocks the current goroutine until a consumer takes it
+func (q *SynchronousQueue[T]) Put(item T) (bool, error) {
+	return q.PutContext(context.Background(), item)
+}
+
+// Takes an element from a producer.
+// It blocks the current goroutine until a producer hands one over
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *SynchronousQueue[T]) GetContext(ctx context.Context) (T, error) {
+	q.lock.Lock()
+
+	if producer := q.match(q.producers); producer != nil {
+		q.lock.Unlock()
+		close(producer.matched)
+
+		return producer.item, nil
+	}
+
+	var consumer = &syncWaiter[T]{matched: make(chan struct{})}
+	if err := awaitMatch(ctx, q.lock, q.consumers, consumer); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return consumer.item, nil
+}
+
+// Hands the element to a consumer.
+// It blocks the current goroutine until a consumer takes it
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *SynchronousQueue[T]) PutContext(ctx context.Context, item T) (bool, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.lock.Lock()
+
+	if consumer := q.match(q.consumers); consumer != nil {
+		q.lock.Unlock()
+		consumer.item = item
+		close(consumer.matched)
+
+		return true, nil
+	}
+
+	var producer = &syncWaiter[T]{item: item, matched: make(chan struct{})}
+	if err := awaitMatch(ctx, q.lock, q.producers, producer); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
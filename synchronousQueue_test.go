@@ -0,0 +1,155 @@
+package blockingQueues
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type SynchronousQueueSuite struct {
+	queue *SynchronousQueue[interface{}]
+}
+
+var _ = Suite(&SynchronousQueueSuite{})
+
+func (s *SynchronousQueueSuite) SetUpTest(c *C) {
+	s.queue = NewSynchronousQueue(true)
+}
+
+// Waits until n goroutines are blocked in waiters
+func waitForWaiters(q *SynchronousQueue[interface{}], producers bool, n int) {
+	for {
+		q.lock.Lock()
+		var waiting = q.consumers.Len()
+		if producers {
+			waiting = q.producers.Len()
+		}
+		q.lock.Unlock()
+
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *SynchronousQueueSuite) TestEmpty(c *C) {
+	c.Assert(s.queue.Size(), Equals, uint64(0))
+	c.Assert(s.queue.Capacity(), Equals, uint64(0))
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+	c.Assert(s.queue.Peek(), IsNil)
+}
+
+func (s *SynchronousQueueSuite) TestOfferWithoutConsumer(c *C) {
+	c.Assert(s.queue.Offer(1), Equals, false)
+
+	res, err := s.queue.Push(1)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, ErrorFull)
+
+	item, err := s.queue.Pop()
+	c.Assert(item, IsNil)
+	c.Assert(err, Equals, ErrorEmpty)
+}
+
+func (s *SynchronousQueueSuite) TestOfferToWaitingConsumer(c *C) {
+	done := make(chan interface{})
+
+	go func() {
+		item, _ := s.queue.Get()
+		done <- item
+	}()
+
+	waitForWaiters(s.queue, false, 1)
+	c.Assert(s.queue.Offer(1), Equals, true)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *SynchronousQueueSuite) TestPutBlocksUntilTaken(c *C) {
+	done := make(chan bool)
+
+	go func() {
+		res, _ := s.queue.Put(1)
+		done <- res
+	}()
+
+	waitForWaiters(s.queue, true, 1)
+	select {
+	case <-done:
+		c.Error("Put returned before the item was taken")
+	default:
+	}
+
+	item, err := s.queue.Pop()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+	c.Assert(<-done, Equals, true)
+}
+
+func (s *SynchronousQueueSuite) TestPutContextCancel(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	res, err := s.queue.PutContext(ctx, 1)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	_, err = s.queue.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+}
+
+func (s *SynchronousQueueSuite) TestGetContextCancel(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	item, err := s.queue.GetContext(ctx)
+	c.Assert(item, IsNil)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(s.queue.Offer(1), Equals, false)
+}
+
+func (s *SynchronousQueueSuite) TestFairOrder(c *C) {
+	q := NewSynchronousQueue(true)
+	done := make(chan bool)
+
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			q.Put(i)
+			done <- true
+		}(i)
+		waitForWaiters(q, true, i+1)
+	}
+
+	for i := 0; i < 3; i++ {
+		item, _ := q.Get()
+		c.Assert(item, Equals, i)
+		<-done
+	}
+}
+
+func (s *SynchronousQueueSuite) TestUnfairOrder(c *C) {
+	q := NewSynchronousQueue(false)
+	done := make(chan bool)
+
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			q.Put(i)
+			done <- true
+		}(i)
+		waitForWaiters(q, true, i+1)
+	}
+
+	for i := 2; i >= 0; i-- {
+		item, _ := q.Get()
+		c.Assert(item, Equals, i)
+		<-done
+	}
+}
+
+func (s *SynchronousQueueSuite) BenchmarkPut1to1(c *C) {
+	benchmarkPut(c, 1, 1, s.queue)
+}
+
+func (s *SynchronousQueueSuite) BenchmarkPut4to4(c *C) {
+	benchmarkPut(c, 4, 4, s.queue)
+}
@@ -0,0 +1,322 @@
+package blockingQueues
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Item of a TransferQueue along with its producer hand-off state
+type transferNode[T any] struct {
+	item T
+
+	// Receives whether the item reached a consumer, nil for plain puts
+	received chan bool
+
+	// Whether the producer gave up on the transfer, consumers skip it
+	cancelled bool
+}
+
+// Consumer blocked in Get, waiting for a producer to hand it a node
+type transferWaiter[T any] struct {
+	node  *transferNode[T]
+	ready *sync.Cond
+}
+
+/**
+ * TransferQueue is an unbounded multi-producer, multi-consumer queue
+ * where producers may wait for their items to be received by a consumer
+ */
+
+type TransferQueue[T any] struct {
+	// The number of items in the Queue, excluding cancelled transfers
+	count uint64
+
+	// The number of nodes in the store, including cancelled transfers
+	nodes uint64
+
+	// The consumers blocked in Get, in arrival order
+	waiters *list.List
+
+	// Main lock guarding all access
+	lock *sync.Mutex
+
+	// The underling store
+	store *LinkedListStore[*transferNode[T]]
+}
+
+// Creates an empty TransferQueue
+func NewTransferQueue() *TransferQueue[interface{}] {
+	return NewTransferQueueOf[interface{}]()
+}
+
+// Creates an empty TransferQueue of T
+func NewTransferQueueOf[T any]() *TransferQueue[T] {
+	lock := new(sync.Mutex)
+
+	return &TransferQueue[T]{
+		lock:    lock,
+		waiters: list.New(),
+		count:   uint64(0),
+		store:   NewLinkedListStore[*transferNode[T]](Unbounded),
+	}
+}
+
+var _ Interface[interface{}] = (*TransferQueue[interface{}])(nil)
+
+// Hands node to the longest waiting consumer, or appends it at the tail
+// if there is none. Call only when holding lock.
+func (q *TransferQueue[T]) push(node *transferNode[T]) {
+	if e := q.waiters.Front(); e != nil {
+		// The queue is empty while consumers wait
+		var waiter = q.waiters.Remove(e).(*transferWaiter[T])
+		waiter.node = node
+		waiter.ready.Signal()
+
+		if node.received != nil {
+			node.received <- true
+		}
+		return
+	}
+
+	q.store.Set(node, 0)
+	q.nodes += 1
+	q.count += 1
+}
+
+// Drops the cancelled transfers at the head. Call only when holding lock.
+func (q *TransferQueue[T]) skipCancelled() {
+	for q.nodes > 0 && q.store.Get(0).cancelled {
+		q.store.Remove(0)
+		q.nodes -= 1
+	}
+}
+
+// Pops the head item and notifies its producer. Call only when holding lock.
+func (q *TransferQueue[T]) pop() T {
+	q.skipCancelled()
+
+	var node = q.store.Remove(0)
+	q.nodes -= 1
+	q.count -= 1
+
+	if node.received != nil {
+		node.received <- true
+	}
+
+	return node.item
+}
+
+// Size returns this current elements size, is concurrent safe
+func (q *TransferQueue[T]) Size() uint64 {
+	q.lock.Lock()
+	res := q.count
+	q.lock.Unlock()
+
+	return res
+}
+
+// Capacity always returns Unbounded
+func (q *TransferQueue[T]) Capacity() uint64 {
+	return Unbounded
+}
+
+func (q *TransferQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Clears all the queues elements. Pending transfers of cleared
+// elements return false
+func (q *TransferQueue[T]) Clear() {
+	q.lock.Lock()
+
+	for ; q.nodes > 0; q.nodes -= 1 {
+		var node = q.store.Remove(0)
+		if node.received != nil && !node.cancelled {
+			node.received <- false
+		}
+	}
+	q.count = uint64(0)
+	q.lock.Unlock()
+}
+
+// Just attempts to return the head element of the queue
+func (q *TransferQueue[T]) Peek() T {
+	q.lock.Lock()
+
+	var res T
+
+	if q.count > 0 {
+		q.skipCancelled()
+		res = q.store.Get(0).item
+	}
+	q.lock.Unlock()
+
+	return res
+}
+
+// Pushes the specified element at the tail of the queue.
+// Never blocks the current goroutine as the queue is unbounded
+func (q *TransferQueue[T]) Push(item T) (bool, error) {
+	return q.Offer(item), nil
+}
+
+// Inserts the specified element at the tail of the queue.
+// Never blocks the current goroutine as the queue is unbounded
+func (q *TransferQueue[T]) Offer(item T) bool {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.lock.Lock()
+	q.push(&transferNode[T]{item: item})
+	q.lock.Unlock()
+
+	return true
+}
+
+// Puts the specified element at the tail of the queue.
+// Never blocks the current goroutine as the queue is unbounded
+func (q *TransferQueue[T]) Put(item T) (bool, error) {
+	return q.Offer(item), nil
+}
+
+// Puts the specified element at the tail of the queue.
+// Never blocks the current goroutine as the queue is unbounded
+func (q *TransferQueue[T]) PutContext(ctx context.Context, item T) (bool, error) {
+	return q.Offer(item), nil
+}
+
+// Pops an element from the head of the queue.
+// Does not block the current goroutine
+func (q *TransferQueue[T]) Pop() (res T, err error) {
+	q.lock.Lock()
+
+	if q.count == 0 {
+		err = ErrorEmpty
+	} else {
+		res = q.pop()
+	}
+	q.lock.Unlock()
+
+	return
+}
+
+// Takes an element from the head of the queue.
+// It blocks the current goroutine if the queue is Empty until notified
+func (q *TransferQueue[T]) Get() (T, error) {
+	return q.GetContext(context.Background())
+}
+
+// Takes an element from the head of the queue.
+// It blocks the current goroutine if the queue is Empty until notified
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *TransferQueue[T]) GetContext(ctx context.Context) (T, error) {
+	q.lock.Lock()
+
+	if q.count > 0 {
+		var item = q.pop()
+		q.lock.Unlock()
+
+		return item, nil
+	}
+
+	var waiter = &transferWaiter[T]{ready: sync.NewCond(q.lock)}
+	var e = q.waiters.PushBack(waiter)
+
+	// Wake up the waiter so it can notice the cancellation
+	stop := afterFunc(ctx, func() {
+		q.lock.Lock()
+		waiter.ready.Signal()
+		q.lock.Unlock()
+	})
+
+	for waiter.node == nil {
+		if err := ctx.Err(); err != nil {
+			q.waiters.Remove(e)
+			q.lock.Unlock()
+			stop()
+
+			var zero T
+			return zero, err
+		}
+		// We wait here until a producer hands us an item
+		waiter.ready.Wait()
+	}
+	q.lock.Unlock()
+	stop()
+
+	return waiter.node.item, nil
+}
+
+// Puts the specified element at the tail of the queue and blocks the
+// current goroutine until a consumer receives it. Returns false if the
+// element was discarded by Clear
+func (q *TransferQueue[T]) Transfer(item T) (bool, error) {
+	return q.TransferContext(context.Background(), item)
+}
+
+// Puts the specified element at the tail of the queue and blocks the
+// current goroutine until a consumer receives it or until ctx is done,
+// in which case the element is withdrawn and ctx.Err() returned.
+// Returns false if the element was discarded by Clear
+func (q *TransferQueue[T]) TransferContext(ctx context.Context, item T) (bool, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	var node = &transferNode[T]{item: item, received: make(chan bool, 1)}
+
+	q.lock.Lock()
+	q.push(node)
+	q.lock.Unlock()
+
+	select {
+	case res := <-node.received:
+		return res, nil
+	case <-ctx.Done():
+	}
+
+	q.lock.Lock()
+
+	select {
+	case res := <-node.received:
+		// Received while we were giving up
+		q.lock.Unlock()
+		return res, nil
+	default:
+	}
+
+	node.cancelled = true
+	q.count -= 1
+	q.lock.Unlock()
+
+	return false, ctx.Err()
+}
+
+// Puts the specified element at the tail of the queue and waits up to
+// timeout for a consumer to receive it. If none does, the element is
+// withdrawn and false returned. A consumer blocked in Get receives it
+// right away, even with a zero timeout
+func (q *TransferQueue[T]) TryTransfer(item T, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	res, _ := q.TransferContext(ctx, item)
+	cancel()
+
+	return res
+}
+
+// Reports whether at least one consumer is blocked in Get
+func (q *TransferQueue[T]) HasWaitingConsumer() bool {
+	return q.GetWaitingConsumerCount() > 0
+}
+
+// Returns the number of consumers blocked in Get
+func (q *TransferQueue[T]) GetWaitingConsumerCount() uint64 {
+	q.lock.Lock()
+	res := uint64(q.waiters.Len())
+	q.lock.Unlock()
+
+	return res
+}
@@ -0,0 +1,168 @@
+package blockingQueues
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type TransferQueueSuite struct {
+	queue *TransferQueue[interface{}]
+}
+
+var _ = Suite(&TransferQueueSuite{})
+
+func (s *TransferQueueSuite) SetUpTest(c *C) {
+	s.queue = NewTransferQueue()
+}
+
+// Waits until n consumers are blocked in Get
+func waitForConsumers(q *TransferQueue[interface{}], n uint64) {
+	for q.GetWaitingConsumerCount() != n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *TransferQueueSuite) TestUnbounded(c *C) {
+	for i := 0; i < 100; i += 1 {
+		res, err := s.queue.Put(i)
+		c.Assert(res, Equals, true)
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(s.queue.Size(), Equals, uint64(100))
+	c.Assert(s.queue.Peek(), Equals, 0)
+
+	for i := 0; i < 100; i += 1 {
+		item, err := s.queue.Pop()
+		c.Assert(err, IsNil)
+		c.Assert(item, Equals, i)
+	}
+
+	_, err := s.queue.Pop()
+	c.Assert(err, Equals, ErrorEmpty)
+}
+
+func (s *TransferQueueSuite) TestWaitingConsumers(c *C) {
+	c.Assert(s.queue.HasWaitingConsumer(), Equals, false)
+
+	done := make(chan interface{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			item, _ := s.queue.Get()
+			done <- item
+		}()
+	}
+
+	waitForConsumers(s.queue, 2)
+	c.Assert(s.queue.HasWaitingConsumer(), Equals, true)
+
+	s.queue.Put(1)
+	s.queue.Put(2)
+	<-done
+	<-done
+	c.Assert(s.queue.GetWaitingConsumerCount(), Equals, uint64(0))
+}
+
+func (s *TransferQueueSuite) TestTransferBlocksUntilReceived(c *C) {
+	done := make(chan bool)
+
+	go func() {
+		res, _ := s.queue.Transfer(1)
+		done <- res
+	}()
+
+	for s.queue.Size() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		c.Error("Transfer returned before the item was received")
+	default:
+	}
+
+	item, err := s.queue.Get()
+	c.Assert(err, IsNil)
+	c.Assert(item, Equals, 1)
+	c.Assert(<-done, Equals, true)
+}
+
+func (s *TransferQueueSuite) TestTryTransferToWaitingConsumer(c *C) {
+	done := make(chan interface{})
+
+	go func() {
+		item, _ := s.queue.Get()
+		done <- item
+	}()
+
+	waitForConsumers(s.queue, 1)
+	c.Assert(s.queue.TryTransfer(1, time.Second), Equals, true)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *TransferQueueSuite) TestTryTransferZeroTimeout(c *C) {
+	done := make(chan interface{})
+
+	for i := 0; i < 200; i += 1 {
+		go func() {
+			item, _ := s.queue.Get()
+			done <- item
+		}()
+
+		waitForConsumers(s.queue, 1)
+		c.Assert(s.queue.TryTransfer(i, 0), Equals, true)
+		c.Assert(<-done, Equals, i)
+	}
+	c.Assert(s.queue.TryTransfer(-1, 0), Equals, false)
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+}
+
+func (s *TransferQueueSuite) TestTryTransferTimeout(c *C) {
+	s.queue.Put(1)
+
+	c.Assert(s.queue.TryTransfer(2, 10*time.Millisecond), Equals, false)
+	c.Assert(s.queue.Size(), Equals, uint64(1))
+
+	s.queue.Put(3)
+
+	item, _ := s.queue.Pop()
+	c.Assert(item, Equals, 1)
+	item, _ = s.queue.Pop()
+	c.Assert(item, Equals, 3)
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+}
+
+func (s *TransferQueueSuite) TestTransferContextCancel(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := s.queue.TransferContext(ctx, 1)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(s.queue.Peek(), IsNil)
+}
+
+func (s *TransferQueueSuite) TestClearReleasesTransfers(c *C) {
+	done := make(chan bool)
+
+	go func() {
+		res, _ := s.queue.Transfer(1)
+		done <- res
+	}()
+
+	for s.queue.Size() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	s.queue.Clear()
+
+	c.Assert(<-done, Equals, false)
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+}
+
+func (s *TransferQueueSuite) BenchmarkPut1to1(c *C) {
+	benchmarkPut(c, 1, 1, s.queue)
+}
+
+func (s *TransferQueueSuite) BenchmarkPut4to4(c *C) {
+	benchmarkPut(c, 4, 4, s.queue)
+}
@@ -0,0 +1,331 @@
+package blockingQueues
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Singly linked node of a TwoLockLinkedBlockingQueue
+type twoLockNode[T any] struct {
+	item T
+	next *twoLockNode[T]
+}
+
+/**
+ * TwoLockLinkedBlockingQueue is A multi-producer, multi-consumer linked queue
+ * using separate locks for the head and the tail (Michael-Scott two-lock
+ * algorithm), so that producers and consumers proceed in parallel
+ */
+
+type TwoLockLinkedBlockingQueue[T any] struct {
+	// The number of items in the Queue, accessed atomically
+	count uint64
+
+	// The maximum number of items in the Queue
+	capacity uint64
+
+	// Lock held by Get, Pop, Peek
+	takeLock *sync.Mutex
+
+	// Condition for waiting reads
+	notEmpty *sync.Cond
+
+	// Lock held by Put, Push, Offer
+	putLock *sync.Mutex
+
+	// Condition for waiting writes
+	notFull *sync.Cond
+
+	// Dummy node whose next is the first item, guarded by takeLock
+	head *twoLockNode[T]
+
+	// The last node, guarded by putLock
+	tail *twoLockNode[T]
+}
+
+// Creates an TwoLockLinkedBlockingQueue with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewTwoLockLinkedBlockingQueue(capacity uint64) (*TwoLockLinkedBlockingQueue[interface{}], error) {
+	return NewTwoLockLinkedBlockingQueueOf[interface{}](capacity)
+}
+
+// Creates an TwoLockLinkedBlockingQueue of T with the given (fixed) capacity
+// returns an error if the capacity is less than 1
+func NewTwoLockLinkedBlockingQueueOf[T any](capacity uint64) (*TwoLockLinkedBlockingQueue[T], error) {
+	if capacity < 1 {
+		return nil, ErrorCapacity
+	}
+
+	takeLock := new(sync.Mutex)
+	putLock := new(sync.Mutex)
+	dummy := new(twoLockNode[T])
+
+	return &TwoLockLinkedBlockingQueue[T]{
+		capacity: capacity,
+		takeLock: takeLock,
+		notEmpty: sync.NewCond(takeLock),
+		putLock:  putLock,
+		notFull:  sync.NewCond(putLock),
+		head:     dummy,
+		tail:     dummy,
+	}, nil
+}
+
+var _ Interface[interface{}] = (*TwoLockLinkedBlockingQueue[interface{}])(nil)
+
+// Links item at the tail. Call only when holding putLock.
+func (q *TwoLockLinkedBlockingQueue[T]) enqueue(item T) {
+	q.tail.next = &twoLockNode[T]{item: item}
+	q.tail = q.tail.next
+}
+
+// Unlinks the item at the head. Call only when holding takeLock.
+func (q *TwoLockLinkedBlockingQueue[T]) dequeue() T {
+	var first = q.head.next
+	var item = first.item
+	var zero T
+
+	// first becomes the new dummy node
+	first.item = zero
+	q.head = first
+
+	return item
+}
+
+// Wakes up a waiting reader. Call only when not holding takeLock.
+func (q *TwoLockLinkedBlockingQueue[T]) signalNotEmpty() {
+	q.takeLock.Lock()
+	q.notEmpty.Signal()
+	q.takeLock.Unlock()
+}
+
+// Wakes up a waiting writer. Call only when not holding putLock.
+func (q *TwoLockLinkedBlockingQueue[T]) signalNotFull() {
+	q.putLock.Lock()
+	q.notFull.Signal()
+	q.putLock.Unlock()
+}
+
+// Links item at the tail, signals and returns the previous count.
+// Call only when holding putLock and the queue is not full.
+func (q *TwoLockLinkedBlockingQueue[T]) push(item T) uint64 {
+	q.enqueue(item)
+	var c = atomic.AddUint64(&q.count, 1) - 1
+
+	if c+1 < q.capacity {
+		// Let other writers know there is still room
+		q.notFull.Signal()
+	}
+
+	return c
+}
+
+// Unlinks the item at the head, signals and returns it with the previous count.
+// Call only when holding takeLock and the queue is not empty.
+func (q *TwoLockLinkedBlockingQueue[T]) pop() (T, uint64) {
+	var item = q.dequeue()
+	var c = atomic.AddUint64(&q.count, ^uint64(0)) + 1
+
+	if c > 1 {
+		// Let other readers know there are still items
+		q.notEmpty.Signal()
+	}
+
+	return item, c
+}
+
+// Size returns this current elements size, is concurrent safe
+func (q *TwoLockLinkedBlockingQueue[T]) Size() uint64 {
+	return atomic.LoadUint64(&q.count)
+}
+
+// Capacity returns this current elements remaining capacity, is concurrent safe
+func (q *TwoLockLinkedBlockingQueue[T]) Capacity() uint64 {
+	return q.capacity - atomic.LoadUint64(&q.count)
+}
+
+func (q *TwoLockLinkedBlockingQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
+
+// Clears all the queues elements, signals waiters for queue is empty
+func (q *TwoLockLinkedBlockingQueue[T]) Clear() {
+	q.putLock.Lock()
+	q.takeLock.Lock()
+
+	q.head = new(twoLockNode[T])
+	q.tail = q.head
+	atomic.StoreUint64(&q.count, 0)
+	q.notFull.Broadcast()
+
+	q.takeLock.Unlock()
+	q.putLock.Unlock()
+}
+
+// Pushes the specified element at the tail of the queue.
+// Does not block the current goroutine
+func (q *TwoLockLinkedBlockingQueue[T]) Push(item T) (bool, error) {
+	if q.Offer(item) {
+		return true, nil
+	} else {
+		return false, ErrorFull
+	}
+}
+
+// Inserts the specified element at the tail of this queue if it is possible to
+// do so immediately without exceeding the queue's capacity,
+// returning true upon success and false if this queue is full.
+// Does not block the current goroutine
+func (q *TwoLockLinkedBlockingQueue[T]) Offer(item T) bool {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	if atomic.LoadUint64(&q.count) == q.capacity {
+		return false
+	}
+
+	q.putLock.Lock()
+
+	if atomic.LoadUint64(&q.count) == q.capacity {
+		q.putLock.Unlock()
+		return false
+	}
+	var c = q.push(item)
+	q.putLock.Unlock()
+
+	if c == 0 {
+		q.signalNotEmpty()
+	}
+
+	return true
+}
+
+// Pops an element from the head of the queue.
+// Does not block the current goroutine
+func (q *TwoLockLinkedBlockingQueue[T]) Pop() (T, error) {
+	var zero T
+
+	if atomic.LoadUint64(&q.count) == 0 {
+		return zero, ErrorEmpty
+	}
+
+	q.takeLock.Lock()
+
+	if atomic.LoadUint64(&q.count) == 0 {
+		q.takeLock.Unlock()
+		return zero, ErrorEmpty
+	}
+	var item, c = q.pop()
+	q.takeLock.Unlock()
+
+	if c == q.capacity {
+		q.signalNotFull()
+	}
+
+	return item, nil
+}
+
+// Just attempts to return the head element of the queue
+func (q *TwoLockLinkedBlockingQueue[T]) Peek() T {
+	q.takeLock.Lock()
+
+	var res T
+
+	if atomic.LoadUint64(&q.count) > 0 {
+		res = q.head.next.item
+	}
+	q.takeLock.Unlock()
+
+	return res
+}
+
+// Takes an element from the head of the queue.
+// It blocks the current goroutine if the queue is Empty until notified
+func (q *TwoLockLinkedBlockingQueue[T]) Get() (T, error) {
+	return q.GetContext(context.Background())
+}
+
+// Puts an element to the tail of the queue.
+// It blocks the current goroutine if the queue is Full until notified
+func (q *TwoLockLinkedBlockingQueue[T]) Put(item T) (bool, error) {
+	return q.PutContext(context.Background(), item)
+}
+
+// Takes an element from the head of the queue.
+// It blocks the current goroutine if the queue is Empty until notified
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *TwoLockLinkedBlockingQueue[T]) GetContext(ctx context.Context) (T, error) {
+	q.takeLock.Lock()
+
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, func() {
+		q.takeLock.Lock()
+		q.notEmpty.Broadcast()
+		q.takeLock.Unlock()
+	})
+
+	for atomic.LoadUint64(&q.count) == 0 {
+		if err := ctx.Err(); err != nil {
+			q.takeLock.Unlock()
+			stop()
+
+			var zero T
+			return zero, err
+		}
+		// We wait here until the queue has an item
+		q.notEmpty.Wait()
+	}
+
+	// Critical section after wait released and predicate is false
+	var item, c = q.pop()
+	q.takeLock.Unlock()
+	stop()
+
+	if c == q.capacity {
+		q.signalNotFull()
+	}
+
+	return item, nil
+}
+
+// Puts an element to the tail of the queue.
+// It blocks the current goroutine if the queue is Full until notified
+// or until ctx is done, in which case it returns ctx.Err()
+func (q *TwoLockLinkedBlockingQueue[T]) PutContext(ctx context.Context, item T) (bool, error) {
+	if any(item) == nil {
+		panic("Null item")
+	}
+
+	q.putLock.Lock()
+
+	// Wake up the waiters so the cancelled one can notice
+	stop := afterFunc(ctx, func() {
+		q.putLock.Lock()
+		q.notFull.Broadcast()
+		q.putLock.Unlock()
+	})
+
+	for atomic.LoadUint64(&q.count) == q.capacity {
+		if err := ctx.Err(); err != nil {
+			q.putLock.Unlock()
+			stop()
+
+			return false, err
+		}
+		// We wait here until the queue has an empty slot
+		q.notFull.Wait()
+	}
+
+	// Critical section after wait released and predicate is false
+	var c = q.push(item)
+	q.putLock.Unlock()
+	stop()
+
+	if c == 0 {
+		q.signalNotEmpty()
+	}
+
+	return true, nil
+}
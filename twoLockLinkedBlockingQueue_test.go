@@ -0,0 +1,251 @@
+package blockingQueues
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"math"
+	"sync"
+	"time"
+)
+
+type TwoLockLinkedBlockingQueueSuite struct {
+	queue  *TwoLockLinkedBlockingQueue[interface{}]
+	queue2 *TwoLockLinkedBlockingQueue[interface{}]
+}
+
+var _ = Suite(&TwoLockLinkedBlockingQueueSuite{})
+
+func (s *TwoLockLinkedBlockingQueueSuite) SetUpTest(c *C) {
+	s.queue, _ = NewTwoLockLinkedBlockingQueue(16)
+	s.queue2, _ = NewTwoLockLinkedBlockingQueue(1024)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestInvalidCapacity(c *C) {
+	_, err := NewTwoLockLinkedBlockingQueue(0)
+	c.Assert(err, ErrorMatches, "ERROR_CAPACITY: attempt to Create Queue with invalid Capacity")
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestConstructor(c *C) {
+	q, err := NewTwoLockLinkedBlockingQueue(16)
+
+	c.Assert(err, IsNil)
+	c.Assert(q.Capacity(), Equals, uint64(16))
+	c.Assert(q.Size(), Equals, uint64(0))
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestPush(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+
+	res, err := s.queue.Push(17)
+	c.Assert(res, Equals, false)
+	c.Assert(err, ErrorMatches, "ERROR_FULL: attempt to Put while Queue is Full")
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestPop(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	for i := 0; i < 10; i += 1 {
+		item, _ := s.queue.Pop()
+		c.Assert(item, Equals, i)
+	}
+
+	c.Assert(s.queue.Size(), Equals, uint64(0))
+
+	res, err := s.queue.Pop()
+	c.Assert(res, IsNil)
+	c.Assert(err, ErrorMatches, "ERROR_EMPTY: attempt to Get while Queue is Empty")
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestClear(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	s.queue.Clear()
+
+	c.Assert(s.queue.Size(), Equals, uint64(0))
+	c.Assert(s.queue.Peek(), IsNil)
+
+	s.queue.Push(1)
+	c.Assert(s.queue.Peek(), Equals, 1)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestPeek(c *C) {
+	for i := 0; i < 10; i += 1 {
+		s.queue.Push(i)
+	}
+
+	c.Assert(s.queue.Peek(), Equals, 0)
+
+	s.queue.Pop()
+
+	c.Assert(s.queue.Peek(), Equals, 1)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestPutPanicsOnNil(c *C) {
+	defer func() {
+		if r := recover(); r == nil {
+			c.Errorf("TestPutPanicsOnNil should have panicked!")
+		}
+	}()
+
+	s.queue.Put(nil)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestPutBlocks(c *C) {
+	for i := 0; i < 16; i += 1 {
+		s.queue.Push(i)
+	}
+
+	done := make(chan bool)
+	go func() {
+		s.queue.Put(16)
+		done <- true
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-done:
+		c.Error("Put did not block")
+	default:
+	}
+
+	item, _ := s.queue.Get()
+	c.Assert(item, Equals, 0)
+	<-done
+	c.Assert(s.queue.Size(), Equals, uint64(16))
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestGetBlocks(c *C) {
+	done := make(chan interface{})
+	go func() {
+		item, _ := s.queue.Get()
+		done <- item
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.queue.Put(1)
+	c.Assert(<-done, Equals, 1)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestContext(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := s.queue.GetContext(ctx)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	for i := 0; i < 16; i += 1 {
+		s.queue.Put(i)
+	}
+
+	res, err := s.queue.PutContext(ctx, 16)
+	c.Assert(res, Equals, false)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) TestConcurrentFIFO(c *C) {
+	writers, items := 4, 1000
+	wg := sync.WaitGroup{}
+
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			for i := 0; i < items; i++ {
+				s.queue.Put([2]int{writer, i})
+			}
+			wg.Done()
+		}(writer)
+	}
+
+	next := make([]int, writers)
+	for i := 0; i < writers*items; i++ {
+		item, err := s.queue.Get()
+		c.Assert(err, IsNil)
+
+		pair := item.([2]int)
+		c.Assert(pair[1], Equals, next[pair[0]])
+		next[pair[0]] += 1
+	}
+
+	wg.Wait()
+	c.Assert(s.queue.IsEmpty(), Equals, true)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPeek(c *C) {
+	q, _ := NewTwoLockLinkedBlockingQueue(math.MaxUint16)
+
+	q.Push(1)
+
+	c.ResetTimer()
+
+	for i := 0; i < c.N; i++ {
+		q.Peek()
+	}
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPop(c *C) {
+	q, _ := NewTwoLockLinkedBlockingQueue(math.MaxUint16)
+
+	for i := 0; i < c.N; i++ {
+		q.Push(i)
+	}
+
+	c.ResetTimer()
+
+	for i := 0; i < c.N; i++ {
+		q.Pop()
+	}
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPush(c *C) {
+	q, _ := NewTwoLockLinkedBlockingQueue(math.MaxUint16)
+
+	c.ResetTimer()
+
+	for i := 0; i < c.N; i++ {
+		q.Push(i)
+	}
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut1to1(c *C) {
+	benchmarkPut(c, 1, 1, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut2to2(c *C) {
+	benchmarkPut(c, 2, 2, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut4to4(c *C) {
+	benchmarkPut(c, 4, 4, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut4to1(c *C) {
+	benchmarkPutMoreWriters(c, 4, 1, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut2to1(c *C) {
+	benchmarkPutMoreWriters(c, 2, 1, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut3to2(c *C) {
+	benchmarkPutMoreWriters(c, 3, 2, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut1to3(c *C) {
+	benchmarkPutMoreReaders(c, 1, 3, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut2to3(c *C) {
+	benchmarkPutMoreReaders(c, 2, 3, s.queue2)
+}
+
+func (s *TwoLockLinkedBlockingQueueSuite) BenchmarkPut1to4(c *C) {
+	benchmarkPutMoreReaders(c, 1, 4, s.queue2)
+}